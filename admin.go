@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// [관리] 관리 페이지용 서버 현황 집계 (/api/admin/status)
+
+// 빌드 시 -ldflags "-X main.serverVersion=1.2.3" 으로 지정
+var serverVersion = "dev"
+
+const recentInjectMax = 20
+
+type InjectJob struct {
+	System   string `json:"system"`
+	Rom      string `json:"rom"`
+	Inject   string `json:"inject"`
+	Result   string `json:"result"` // success / skipped / busy / error
+	Started  int64  `json:"started"`
+	Duration int64  `json:"durationMs"`
+}
+
+// 최근 인젝트 작업 (메모리에만 보관, 최신순)
+var recentInjects struct {
+	sync.Mutex
+	jobs []InjectJob
+}
+
+func recordInjectJob(job InjectJob) {
+	recentInjects.Lock()
+	defer recentInjects.Unlock()
+	recentInjects.jobs = append([]InjectJob{job}, recentInjects.jobs...)
+	if len(recentInjects.jobs) > recentInjectMax {
+		recentInjects.jobs = recentInjects.jobs[:recentInjectMax]
+	}
+}
+
+type CoreFileInfo struct {
+	Name    string `json:"name"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"modTime"`
+}
+
+type StorageBreakdown struct {
+	Total    int64            `json:"total"`
+	BySystem map[string]int64 `json:"bySystem"`
+	ByUser   map[string]int64 `json:"byUser"`
+}
+
+type CacheStatus struct {
+	IndexRawBytes  int    `json:"indexRawBytes"`
+	IndexGzipBytes int    `json:"indexGzipBytes"`
+	IndexETag      string `json:"indexETag,omitempty"`
+	LibraryEntries int    `json:"libraryEntries"`
+}
+
+type AdminStatus struct {
+	Version     string            `json:"version"`
+	GoVersion   string            `json:"goVersion"`
+	Started     int64             `json:"started"`
+	Uptime      int64             `json:"uptime"`
+	Disk        map[string]uint64 `json:"disk"`
+	Library     []SystemSummary   `json:"library"`
+	RomCount    int               `json:"romCount"`
+	EmulatorJS  json.RawMessage   `json:"emulatorjs,omitempty"` // version.json 원본
+	Cores       []CoreFileInfo    `json:"cores"`
+	LastSync    SyncInfo          `json:"lastSync"`
+	Injects     []InjectJob       `json:"recentInjects"`
+	ActiveJobs  []string          `json:"activeJobs"`
+	Storage     StorageBreakdown  `json:"storage"`
+	Cache       CacheStatus       `json:"cache"`
+	Sessions    []PlaySession     `json:"sessions"`
+	Netplay     []NetplayRoomInfo `json:"netplayRooms"`
+	Backup      BackupStatus      `json:"backup"`
+	StoreErrors map[string]string `json:"storeErrors,omitempty"`
+}
+
+func listCoreFiles() []CoreFileInfo {
+	cores := []CoreFileInfo{}
+	entries, err := os.ReadDir(filepath.Join(ejsDataDir, "cores"))
+	if err != nil {
+		return cores
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".data") {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			cores = append(cores, CoreFileInfo{Name: e.Name(), Size: info.Size(), ModTime: info.ModTime().Unix()})
+		}
+	}
+	return cores
+}
+
+// 세이브(현재본 + 리비전 + 충돌본)와 상태 저장의 시스템별 / 사용자별 사용량
+func storageBreakdown() (StorageBreakdown, error) {
+	b := StorageBreakdown{BySystem: make(map[string]int64), ByUser: make(map[string]int64)}
+	owners, err := loadSaveOwners()
+
+	entries, _ := os.ReadDir(savesDir)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".sav") {
+			continue
+		}
+		name := e.Name()
+		size := fileSize(filepath.Join(savesDir, name)) + dirSize(revisionDir(name)) + dirSize(filepath.Join(saveConflictsDir, name))
+		sys, _, ok := parseSaveName(name)
+		if !ok {
+			sys = "unknown"
+		}
+		user := owners[name]
+		if user == "" {
+			user = "unknown"
+		}
+		b.BySystem[sys] += size
+		b.ByUser[user] += size
+		b.Total += size
+	}
+
+	filepath.WalkDir(statesDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(statesDir, path)
+		sys, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+		size := fileSize(path)
+		b.BySystem[sys] += size
+		b.Total += size
+		if strings.HasSuffix(path, ".json") {
+			var meta StateSlotMeta
+			if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &meta) == nil && meta.User != "" {
+				b.ByUser[meta.User] += meta.Size + fileSize(strings.TrimSuffix(path, ".json")+".jpg")
+			}
+		}
+		return nil
+	})
+	return b, err
+}
+
+func handleAdminStatus(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+
+	status := AdminStatus{
+		Version:     serverVersion,
+		GoVersion:   runtime.Version(),
+		Started:     serverStartTime.Unix(),
+		Uptime:      int64(time.Since(serverStartTime).Seconds()),
+		Cores:       listCoreFiles(),
+		Backup:      backupStatus(),
+		StoreErrors: make(map[string]string),
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		status.StoreErrors["disk"] = err.Error()
+	}
+	free, total := getDiskUsage(wd)
+	status.Disk = map[string]uint64{"free": free, "total": total}
+
+	library := getLibraryIndex()
+	status.Library = library.summaries
+	status.RomCount = len(library.entries)
+
+	if data, err := os.ReadFile(filepath.Join(ejsDataDir, "version.json")); err == nil && json.Valid(data) {
+		status.EmulatorJS = data
+	}
+	if _, err := coreSyncStore.Load(&status.LastSync); err != nil {
+		status.StoreErrors["coreSync"] = err.Error()
+	}
+
+	recentInjects.Lock()
+	status.Injects = append([]InjectJob{}, recentInjects.jobs...)
+	recentInjects.Unlock()
+
+	status.ActiveJobs = []string{}
+	processingMutex.Lock()
+	for key := range processingFiles {
+		status.ActiveJobs = append(status.ActiveJobs, key)
+	}
+	processingMutex.Unlock()
+	sort.Strings(status.ActiveJobs)
+
+	storage, err := storageBreakdown()
+	if err != nil {
+		status.StoreErrors["saveOwners"] = err.Error()
+	}
+	status.Storage = storage
+
+	indexCache.RLock()
+	status.Cache = CacheStatus{
+		IndexRawBytes:  len(indexCache.RawContent),
+		IndexGzipBytes: len(indexCache.Content),
+		IndexETag:      indexCache.ETag,
+		LibraryEntries: len(library.entries),
+	}
+	indexCache.RUnlock()
+
+	status.Sessions = []PlaySession{}
+	playSessions.Lock()
+	for _, sess := range playSessions.active {
+		status.Sessions = append(status.Sessions, *sess)
+	}
+	playSessions.Unlock()
+	sort.Slice(status.Sessions, func(i, j int) bool { return status.Sessions[i].Started.Before(status.Sessions[j].Started) })
+	status.Netplay = netplayRooms()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
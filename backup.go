@@ -0,0 +1,628 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// [백업] 세이브/상태 저장/설정 파일을 주기적으로 zip 으로 묶어 다른 디스크(USB 등)에 보관
+// 각 zip 에는 manifest.json (파일별 크기 + SHA-256) 이 들어 있어 무결성을 검증할 수 있다
+const (
+	backupManifestName = "manifest.json"
+	backupNamePrefix   = "backup-"
+	backupTimeFormat   = "20060102-150405"
+	backupCheckPeriod  = 10 * time.Minute
+)
+
+type BackupConfig struct {
+	Dir           string `json:"dir"`           // 백업 저장 위치 (예: /media/usb/retro-backup)
+	IntervalHours int    `json:"intervalHours"` // 0 이면 자동 백업 끔
+	Keep          int    `json:"keep"`          // 보관할 백업 개수
+}
+
+type BackupManifestFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+type BackupManifest struct {
+	Created int64                `json:"created"`
+	Reason  string               `json:"reason"` // scheduled / manual / pre-restore
+	Files   []BackupManifestFile `json:"files"`
+}
+
+type BackupInfo struct {
+	Name    string `json:"name"`
+	Size    int64  `json:"size"`
+	Created int64  `json:"created"`
+}
+
+type BackupVerifyResult struct {
+	Name   string   `json:"name"`
+	OK     bool     `json:"ok"`
+	Files  int      `json:"files"`
+	Errors []string `json:"errors"`
+}
+
+type BackupStatus struct {
+	Dir           string `json:"dir"`
+	SameDisk      bool   `json:"sameDisk"` // 백업 위치가 데이터(SD 카드)와 같은 디스크
+	LastRun       int64  `json:"lastRun,omitempty"`
+	LastSuccess   int64  `json:"lastSuccess,omitempty"`
+	LastError     string `json:"lastError,omitempty"`
+	LastErrorTime int64  `json:"lastErrorTime,omitempty"`
+}
+
+var (
+	backupConfigStore = &jsonStore{path: "./data/backup.json"}
+	backupMu          sync.Mutex // 백업 생성/복원 직렬화
+
+	// 마지막 백업 시도 결과 (/api/admin/status 에 표시)
+	lastBackup struct {
+		sync.Mutex
+		run, success, errorTime int64
+		err                     string
+	}
+)
+
+// 백업 대상: 디렉토리는 통째로, 파일은 있으면 포함. index.html 은 coreMap 등 설정을 담고 있음
+func backupSources() []string {
+	return []string{
+		savesDir, saveHistoryDir, saveConflictsDir, statesDir,
+		bookmarkStore.path, injectLogStore.path, coreSyncStore.path, collectionsStore.path,
+		playStatsStore.path, saveOwnersStore.path, backupConfigStore.path, metadataFile, extensionsStore.path, settingsStore.path, controllersStore.path,
+		"index.html",
+	}
+}
+
+const defaultBackupDir = "./data/backups"
+
+func defaultBackupConfig() BackupConfig {
+	return BackupConfig{Dir: defaultBackupDir, IntervalHours: 24, Keep: 7}
+}
+
+// 서버 폴더(정적 파일로 제공되는 범위) 안의 경로인지. 기본 위치는 파일 핸들러가 막으므로 허용
+func backupDirServed(dir string) bool {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return true
+	}
+	if def, _ := filepath.Abs(defaultBackupDir); abs == def {
+		return false
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return true
+	}
+	rel, err := filepath.Rel(wd, abs)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func loadBackupConfig() (BackupConfig, error) {
+	config := defaultBackupConfig()
+	_, err := backupConfigStore.Load(&config)
+	if config.Keep <= 0 {
+		config.Keep = 1
+	}
+	return config, err
+}
+
+// 백업 파일 이름만 허용 (경로 조작 방지)
+func validBackupName(name string) bool {
+	return filepath.Base(name) == name && strings.HasPrefix(name, backupNamePrefix) && strings.HasSuffix(name, ".zip")
+}
+
+// 아카이브 안의 경로 (./data/saves/x.sav → data/saves/x.sav)
+func archivePath(p string) string {
+	return path.Clean(filepath.ToSlash(p))
+}
+
+// 백업 대상 파일 목록 (임시 파일 / .bak 제외)
+func collectBackupFiles() []string {
+	var files []string
+	for _, src := range backupSources() {
+		info, err := os.Stat(src)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			files = append(files, src)
+			continue
+		}
+		filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			name := d.Name()
+			if strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".bak") {
+				return nil
+			}
+			files = append(files, p)
+			return nil
+		})
+	}
+	return files
+}
+
+// 백업 zip 생성 후 보관 개수 초과분 정리
+func runBackup(reason string) (BackupInfo, error) {
+	backupMu.Lock()
+	defer backupMu.Unlock()
+	info, err := createBackup(reason)
+	recordBackupResult(err)
+	return info, err
+}
+
+func recordBackupResult(err error) {
+	now := time.Now().Unix()
+	lastBackup.Lock()
+	defer lastBackup.Unlock()
+	lastBackup.run = now
+	if err != nil {
+		lastBackup.err, lastBackup.errorTime = err.Error(), now
+		return
+	}
+	lastBackup.success = now
+	lastBackup.err = ""
+}
+
+func backupStatus() BackupStatus {
+	config, _ := loadBackupConfig()
+	lastBackup.Lock()
+	defer lastBackup.Unlock()
+	return BackupStatus{
+		Dir:           config.Dir,
+		SameDisk:      backupOnDataDisk(config.Dir),
+		LastRun:       lastBackup.run,
+		LastSuccess:   lastBackup.success,
+		LastError:     lastBackup.err,
+		LastErrorTime: lastBackup.errorTime,
+	}
+}
+
+// 백업 위치가 ./data 와 같은 장치인지 (아직 없는 폴더는 가장 가까운 상위 폴더로 판단)
+func backupOnDataDisk(dir string) bool {
+	var data, target syscall.Stat_t
+	if syscall.Stat("./data", &data) != nil && syscall.Stat(".", &data) != nil {
+		return false
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	for syscall.Stat(abs, &target) != nil {
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return false
+		}
+		abs = parent
+	}
+	return target.Dev == data.Dev
+}
+
+// 시작 시 백업이 SD 카드 고장에 대비가 되는지 알림
+func logBackupTarget() {
+	config, err := loadBackupConfig()
+	if err != nil {
+		logger("backup").Error("백업 설정 읽기 실패", "path", backupConfigStore.path, "err", err)
+		return
+	}
+	if config.IntervalHours > 0 && backupOnDataDisk(config.Dir) {
+		logger("backup").Warn("백업이 데이터와 같은 디스크에 저장됨: SD 카드가 고장나면 백업도 함께 사라집니다. data/backup.json 의 dir 을 USB 디스크 등으로 지정하세요", "dir", config.Dir)
+	}
+}
+
+// backupMu 잠금 상태에서 호출
+func createBackup(reason string) (BackupInfo, error) {
+	config, err := loadBackupConfig()
+	if err != nil {
+		return BackupInfo{}, err
+	}
+	if err := os.MkdirAll(config.Dir, 0755); err != nil {
+		return BackupInfo{}, err
+	}
+
+	now := time.Now()
+	name := backupNamePrefix + now.Format(backupTimeFormat) + ".zip"
+	tmp, err := os.CreateTemp(config.Dir, ".backup-*.tmp")
+	if err != nil {
+		return BackupInfo{}, err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	manifest := BackupManifest{Created: now.Unix(), Reason: reason, Files: []BackupManifestFile{}}
+	archive := zip.NewWriter(tmp)
+
+	// 세이브 쓰기와 겹치지 않도록 잠금 (현재본과 리비전이 어긋난 백업 방지)
+	savesMu.Lock()
+	for _, p := range collectBackupFiles() {
+		entry, err := addBackupFile(archive, p)
+		if err != nil {
+			logger("backup").Warn("파일 추가 실패", "path", p, "err", err)
+			continue
+		}
+		manifest.Files = append(manifest.Files, entry)
+	}
+	savesMu.Unlock()
+
+	data, _ := json.MarshalIndent(manifest, "", "  ")
+	w, err := archive.CreateHeader(&zip.FileHeader{Name: backupManifestName, Method: zip.Deflate, Modified: now})
+	if err == nil {
+		_, err = w.Write(data)
+	}
+	if err == nil {
+		err = archive.Close()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return BackupInfo{}, err
+	}
+	dest := filepath.Join(config.Dir, name)
+	// 같은 초에 두 번 만들면(예: 수동 백업 직후 복원) 기존 백업을 덮어쓰지 않도록 번호를 붙임
+	for i := 2; ; i++ {
+		if _, err := os.Stat(dest); os.IsNotExist(err) {
+			break
+		}
+		name = fmt.Sprintf("%s%s-%d.zip", backupNamePrefix, now.Format(backupTimeFormat), i)
+		dest = filepath.Join(config.Dir, name)
+	}
+	if err := os.Rename(tmpName, dest); err != nil {
+		return BackupInfo{}, err
+	}
+	syncDir(config.Dir)
+	pruneBackups(config)
+
+	info := BackupInfo{Name: name, Size: fileSize(dest), Created: now.Unix()}
+	logger("backup").Info("백업 생성", "name", name, "reason", reason, "files", len(manifest.Files), "bytes", info.Size)
+	return info, nil
+}
+
+func addBackupFile(archive *zip.Writer, p string) (BackupManifestFile, error) {
+	entry := BackupManifestFile{Path: archivePath(p)}
+	f, err := os.Open(p)
+	if err != nil {
+		return entry, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return entry, err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return entry, err
+	}
+	header.Name = entry.Path
+	header.Method = zip.Deflate
+	w, err := archive.CreateHeader(header)
+	if err != nil {
+		return entry, err
+	}
+	h := sha256.New()
+	entry.Size, err = io.Copy(io.MultiWriter(w, h), f)
+	entry.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return entry, err
+}
+
+func listBackups(config BackupConfig) []BackupInfo {
+	entries, _ := os.ReadDir(config.Dir)
+	list := []BackupInfo{}
+	for _, e := range entries {
+		if e.IsDir() || !validBackupName(e.Name()) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		created := info.ModTime()
+		if t, err := time.ParseInLocation(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(e.Name(), backupNamePrefix), ".zip"), time.Local); err == nil {
+			created = t
+		}
+		list = append(list, BackupInfo{Name: e.Name(), Size: info.Size(), Created: created.Unix()})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Created > list[j].Created })
+	return list
+}
+
+func pruneBackups(config BackupConfig) {
+	list := listBackups(config)
+	for i := config.Keep; i < len(list); i++ {
+		os.Remove(filepath.Join(config.Dir, list[i].Name))
+	}
+}
+
+// 매니페스트의 모든 파일을 끝까지 읽어 크기/SHA-256 을 확인 (zip CRC 도 함께 검사됨)
+func verifyBackup(p string) BackupVerifyResult {
+	result := BackupVerifyResult{Name: filepath.Base(p), Errors: []string{}}
+	archive, err := zip.OpenReader(p)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+	defer archive.Close()
+
+	manifest, files, err := readBackupManifest(&archive.Reader)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+	for _, entry := range manifest.Files {
+		f := files[entry.Path]
+		if f == nil {
+			result.Errors = append(result.Errors, "missing: "+entry.Path)
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			result.Errors = append(result.Errors, entry.Path+": "+err.Error())
+			continue
+		}
+		h := sha256.New()
+		n, err := io.Copy(h, rc)
+		rc.Close()
+		switch {
+		case err != nil:
+			result.Errors = append(result.Errors, entry.Path+": "+err.Error())
+		case n != entry.Size || hex.EncodeToString(h.Sum(nil)) != entry.SHA256:
+			result.Errors = append(result.Errors, "checksum mismatch: "+entry.Path)
+		}
+	}
+	result.Files = len(manifest.Files)
+	result.OK = len(result.Errors) == 0
+	return result
+}
+
+func readBackupManifest(archive *zip.Reader) (BackupManifest, map[string]*zip.File, error) {
+	var manifest BackupManifest
+	files := make(map[string]*zip.File)
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+	mf := files[backupManifestName]
+	if mf == nil {
+		return manifest, files, fmt.Errorf("manifest.json 없음")
+	}
+	rc, err := mf.Open()
+	if err != nil {
+		return manifest, files, err
+	}
+	defer rc.Close()
+	if err := json.NewDecoder(rc).Decode(&manifest); err != nil {
+		return manifest, files, fmt.Errorf("manifest.json 파싱 실패: %v", err)
+	}
+	return manifest, files, nil
+}
+
+// 복원 가능한 경로인지: 백업 대상 안쪽의 상대 경로만 허용
+func restorablePath(p string, includeConfig bool) bool {
+	if p == "index.html" {
+		return includeConfig
+	}
+	if path.IsAbs(p) || strings.HasPrefix(p, "../") || p == ".." {
+		return false
+	}
+	for _, src := range backupSources() {
+		root := archivePath(src)
+		if p == root || strings.HasPrefix(p, root+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// 검증 → 복원 전 현재 상태를 백업 → 파일 덮어쓰기. 백업에 없는 현재 파일은 지우지 않음
+func restoreBackup(name string, includeConfig bool) (int, error) {
+	backupMu.Lock()
+	defer backupMu.Unlock()
+	config, err := loadBackupConfig()
+	if err != nil {
+		return 0, err
+	}
+	p := filepath.Join(config.Dir, name)
+	if result := verifyBackup(p); !result.OK {
+		return 0, fmt.Errorf("백업 검증 실패: %s", strings.Join(result.Errors, ", "))
+	}
+	if _, err := createBackup("pre-restore"); err != nil {
+		return 0, fmt.Errorf("복원 전 백업 실패: %v", err)
+	}
+
+	archive, err := zip.OpenReader(p)
+	if err != nil {
+		return 0, err
+	}
+	defer archive.Close()
+	manifest, files, err := readBackupManifest(&archive.Reader)
+	if err != nil {
+		return 0, err
+	}
+
+	savesMu.Lock()
+	collectionsMu.Lock()
+	restored := 0
+	for _, entry := range manifest.Files {
+		if !restorablePath(entry.Path, includeConfig) {
+			continue
+		}
+		data, err := readZipFile(files[entry.Path], entry.Size)
+		if err == nil {
+			err = restoreFile(entry.Path, data)
+		}
+		if err != nil {
+			logger("backup").Error("파일 복원 실패", "path", entry.Path, "err", err)
+			continue
+		}
+		restored++
+	}
+	collectionsMu.Unlock()
+	savesMu.Unlock()
+
+	invalidateRestoredCaches()
+	logger("backup").Info("백업 복원", "name", name, "files", restored)
+	return restored, nil
+}
+
+// 복원된 파일에서 만든 메모리 캐시를 모두 버림
+// (설정/컬렉션/컨트롤러 프로필 등 jsonStore 는 요청마다 파일을 읽으므로 따로 비울 것이 없음)
+func invalidateRestoredCaches() {
+	playSessions.Lock()
+	playSessions.loaded = false // 플레이 통계 (저장하지 않은 하트비트 누적분은 버림)
+	playSessions.dirty = false
+	playSessions.Unlock()
+	resetStorageUsage()      // 사용자별 저장 용량
+	invalidateLibraryIndex() // 검색 인덱스 (메타데이터, 통계, 컬렉션)
+	invalidateIndexCache()   // SSR 메인 페이지 (즐겨찾기, 컬렉션)
+}
+
+// JSON 상태 파일은 해당 저장소의 잠금을 쥔 채로 교체 (진행 중인 읽기-수정-쓰기와 겹치지 않도록)
+func restoreFile(p string, data []byte) error {
+	for _, store := range []*jsonStore{bookmarkStore, injectLogStore, coreSyncStore, collectionsStore, playStatsStore, saveOwnersStore, backupConfigStore, extensionsStore, settingsStore, controllersStore} {
+		if archivePath(store.path) == p {
+			store.mu.Lock()
+			defer store.mu.Unlock()
+			break
+		}
+	}
+	return writeFileAtomic(filepath.FromSlash(p), data, false)
+}
+
+func backupScheduler() {
+	ticker := time.NewTicker(backupCheckPeriod)
+	defer ticker.Stop()
+	for ; ; <-ticker.C {
+		scheduledBackup()
+	}
+}
+
+// 가장 최근 백업이 주기보다 오래되었으면 백업. 설정을 읽지 못하면 백업이 멈춘 것이므로 오류로 남김
+func scheduledBackup() {
+	config, err := loadBackupConfig()
+	if err != nil {
+		logger("backup").Error("백업 설정 읽기 실패, 자동 백업 건너뜀", "path", backupConfigStore.path, "err", err)
+		recordBackupResult(fmt.Errorf("백업 설정 읽기 실패: %v", err))
+		return
+	}
+	if config.IntervalHours <= 0 {
+		return
+	}
+	list := listBackups(config)
+	if len(list) > 0 && time.Since(time.Unix(list[0].Created, 0)) < time.Duration(config.IntervalHours)*time.Hour {
+		return
+	}
+	if _, err := runBackup("scheduled"); err != nil {
+		logger("backup").Error("자동 백업 실패", "err", err)
+	}
+}
+
+// GET  /api/backup                     설정 + 백업 목록
+// PUT  /api/backup/config              설정 변경 {dir, intervalHours, keep}
+// POST /api/backup/run                 지금 백업
+// GET  /api/backup/download?name=
+// POST /api/backup/verify?name=
+// POST /api/backup/restore?name=&config=1   (config=1 이면 index.html 도 복원)
+// 백업에는 모든 세이브와 설정이 들어 있으므로 조회를 포함한 전부가 관리 API
+func handleBackup(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	action := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/backup"), "/")
+	config, err := loadBackupConfig()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	name := r.URL.Query().Get("name")
+	needName := action == "download" || action == "verify" || action == "restore"
+	if needName && !validBackupName(name) {
+		http.Error(w, "Invalid name", 400)
+		return
+	}
+	if needName {
+		if _, err := os.Stat(filepath.Join(config.Dir, name)); err != nil {
+			http.Error(w, "Not found", 404)
+			return
+		}
+	}
+	wantMethod := map[string]string{"": "GET", "config": "PUT", "run": "POST", "download": "GET", "verify": "POST", "restore": "POST"}
+	method, ok := wantMethod[action]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != method {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+
+	switch action {
+	case "":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"config": config, "backups": listBackups(config)})
+
+	case "config":
+		var next BackupConfig
+		if err := json.NewDecoder(r.Body).Decode(&next); err != nil || strings.TrimSpace(next.Dir) == "" || next.IntervalHours < 0 || next.Keep <= 0 {
+			http.Error(w, "Invalid config", 400)
+			return
+		}
+		if backupDirServed(next.Dir) {
+			http.Error(w, "Backup dir must be outside the server folder", 400)
+			return
+		}
+		if err := backupConfigStore.Save(next); err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(next)
+
+	case "run":
+		info, err := runBackup("manual")
+		if err != nil {
+			logger("backup").Error("백업 실패", "err", err)
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(info)
+
+	case "download":
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", name))
+		http.ServeFile(w, r, filepath.Join(config.Dir, name))
+
+	case "verify":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(verifyBackup(filepath.Join(config.Dir, name)))
+
+	case "restore":
+		restored, err := restoreBackup(name, r.URL.Query().Get("config") == "1")
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]int{"restored": restored})
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func backupTestSave(t *testing.T, data string) {
+	t.Helper()
+	if err := os.MkdirAll(savesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(savesDir, "snes-Game.sfc.sav"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 매니페스트는 그대로 두고 p 항목의 내용만 바꾼 복사본을 씀 (디스크에서 깨진 백업 흉내)
+func tamperBackup(t *testing.T, src, dst, p string) {
+	t.Helper()
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		if f.Name == p {
+			data = bytes.ToUpper(data)
+		}
+		w, _ := zw.Create(f.Name)
+		w.Write(data)
+	}
+	zw.Close()
+	if err := os.WriteFile(dst, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackupVerifyAndRestore(t *testing.T) {
+	t.Chdir(t.TempDir())
+	backupTestSave(t, "before the boss")
+	info, err := runBackup("manual")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, _ := loadBackupConfig()
+	if result := verifyBackup(filepath.Join(config.Dir, info.Name)); !result.OK || result.Files == 0 {
+		t.Fatalf("verify = %+v, want ok", result)
+	}
+
+	backupTestSave(t, "after losing to the boss")
+	restored, err := restoreBackup(info.Name, false)
+	if err != nil || restored == 0 {
+		t.Fatalf("restore = %d, %v", restored, err)
+	}
+	if got := currentSave(t, "snes-Game.sfc.sav"); string(got) != "before the boss" {
+		t.Errorf("after restore save = %q", got)
+	}
+	// 복원 전 상태는 pre-restore 백업으로 남아 있어야 함
+	list := listBackups(config)
+	if len(list) != 2 {
+		t.Fatalf("backups = %+v, want the original and a pre-restore backup", list)
+	}
+	if result := verifyBackup(filepath.Join(config.Dir, list[0].Name)); !result.OK {
+		t.Errorf("pre-restore backup verify = %+v", result)
+	}
+}
+
+func TestCorruptBackupIsNotRestored(t *testing.T) {
+	t.Chdir(t.TempDir())
+	backupTestSave(t, "good save")
+	info, err := runBackup("manual")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, _ := loadBackupConfig()
+	p := filepath.Join(config.Dir, info.Name)
+	tamperBackup(t, p, p, "data/saves/snes-Game.sfc.sav")
+
+	result := verifyBackup(p)
+	if result.OK || len(result.Errors) != 1 || !strings.Contains(result.Errors[0], "checksum mismatch") {
+		t.Fatalf("verify = %+v, want a checksum mismatch", result)
+	}
+	backupTestSave(t, "current save")
+	if _, err := restoreBackup(info.Name, false); err == nil {
+		t.Fatal("restoring a corrupt backup succeeded")
+	}
+	if got := currentSave(t, "snes-Game.sfc.sav"); string(got) != "current save" {
+		t.Errorf("failed restore changed the save to %q", got)
+	}
+}
+
+func TestScheduledBackupReportsConfigError(t *testing.T) {
+	t.Chdir(t.TempDir())
+	recordBackupResult(nil)
+	if err := os.MkdirAll("data", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(backupConfigStore.path, []byte("{broken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	scheduledBackup()
+	status := backupStatus()
+	if status.LastError == "" || status.LastErrorTime == 0 {
+		t.Fatalf("status = %+v, want the config error", status)
+	}
+	if list := listBackups(defaultBackupConfig()); len(list) != 0 {
+		t.Errorf("backup ran with an unreadable config: %+v", list)
+	}
+
+	if err := backupConfigStore.Save(defaultBackupConfig()); err != nil {
+		t.Fatal(err)
+	}
+	scheduledBackup()
+	if status := backupStatus(); status.LastError != "" || status.LastSuccess == 0 {
+		t.Errorf("after fixing the config status = %+v", status)
+	}
+	if !backupStatus().SameDisk {
+		t.Errorf("default backup dir is not reported as the data disk")
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// [BIOS] 시스템별 필요/선택 BIOS(cores.go) 검사와 data/bios 업로드
+// 요구 사항 하나는 여러 후보 파일 중 하나만 있으면 충족 (예: PSX 는 지역별 BIOS 중 아무거나)
+const (
+	biosDir      = "./data/bios"
+	maxBiosBytes = 64 << 20
+)
+
+type BiosFile struct {
+	Name string   `json:"name"`
+	Size int64    `json:"size,omitempty"` // 0 이면 크기 검사 안 함
+	MD5  []string `json:"md5,omitempty"`  // 비어 있으면 알려진 덤프가 여러 개라 검사 안 함
+}
+
+type BiosRequirement struct {
+	Description string     `json:"description"`
+	Required    bool       `json:"required"`
+	Files       []BiosFile `json:"files"`
+}
+
+// [API 응답] 요구 사항 하나의 검사 결과
+type BiosCheck struct {
+	Description string   `json:"description"`
+	Required    bool     `json:"required"`
+	Status      string   `json:"status"` // ok / missing / bad / unverified
+	File        string   `json:"file,omitempty"`
+	MD5         string   `json:"md5,omitempty"`
+	Detail      string   `json:"detail,omitempty"`
+	Expected    []string `json:"expected"` // 후보 파일 이름
+}
+
+type BiosSystemReport struct {
+	System  string      `json:"system"`
+	Core    string      `json:"core"`
+	Status  string      `json:"status"` // ok / missing / bad (필요한 BIOS 기준)
+	BiosUrl string      `json:"biosUrl,omitempty"`
+	Checks  []BiosCheck `json:"checks"`
+}
+
+type BiosReport struct {
+	Systems []BiosSystemReport `json:"systems"`
+	Unknown []string           `json:"unknown"` // 목록에 없는 data/bios 파일
+}
+
+// 파일 MD5 캐시 (크기와 수정 시각이 같으면 다시 계산하지 않음)
+var biosHashCache struct {
+	sync.Mutex
+	entries map[string]biosHashEntry
+}
+
+type biosHashEntry struct {
+	size    int64
+	modTime time.Time
+	md5     string
+}
+
+func biosFileMD5(path string, info os.FileInfo) (string, error) {
+	biosHashCache.Lock()
+	defer biosHashCache.Unlock()
+	if e, ok := biosHashCache.entries[path]; ok && e.size == info.Size() && e.modTime.Equal(info.ModTime()) {
+		return e.md5, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	if biosHashCache.entries == nil {
+		biosHashCache.entries = make(map[string]biosHashEntry)
+	}
+	biosHashCache.entries[path] = biosHashEntry{size: info.Size(), modTime: info.ModTime(), md5: sum}
+	return sum, nil
+}
+
+// 파일 하나 검사: ok / bad / unverified (없으면 missing)
+func checkBiosFile(f BiosFile) BiosCheck {
+	check := BiosCheck{Status: "missing", File: f.Name}
+	path := filepath.Join(biosDir, f.Name)
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return check
+	}
+	sum, err := biosFileMD5(path, info)
+	if err != nil {
+		check.Status, check.Detail = "bad", err.Error()
+		return check
+	}
+	check.MD5 = sum
+	switch {
+	case f.Size > 0 && info.Size() != f.Size:
+		check.Status, check.Detail = "bad", "unexpected size"
+	case len(f.MD5) == 0:
+		check.Status = "unverified"
+	case containsFold(f.MD5, sum):
+		check.Status = "ok"
+	default:
+		check.Status, check.Detail = "bad", "unknown dump (md5 mismatch)"
+	}
+	return check
+}
+
+func containsFold(list []string, v string) bool {
+	for _, s := range list {
+		if strings.EqualFold(s, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// 후보 중 가장 좋은 결과 (ok > unverified > bad > missing)
+func checkBiosRequirement(req BiosRequirement) BiosCheck {
+	rank := map[string]int{"ok": 3, "unverified": 2, "bad": 1, "missing": 0}
+	var best BiosCheck
+	for i, f := range req.Files {
+		c := checkBiosFile(f)
+		if i == 0 || rank[c.Status] > rank[best.Status] {
+			best = c
+		}
+	}
+	if best.Status == "missing" {
+		best.File = ""
+	}
+	best.Description = req.Description
+	best.Required = req.Required
+	for _, f := range req.Files {
+		best.Expected = append(best.Expected, f.Name)
+	}
+	return best
+}
+
+// 코어 목록에 있는 모든 BIOS 후보 파일
+func knownBiosFiles() []BiosFile {
+	var files []BiosFile
+	for _, s := range coreRegistry {
+		for _, req := range s.Bios {
+			files = append(files, req.Files...)
+		}
+	}
+	return files
+}
+
+// 롬 폴더 하나의 검사 결과 (필요한 BIOS 는 cores.go 의 코어 목록 기준)
+func biosSystemReport(config Config, sys string) BiosSystemReport {
+	info, _ := lookupSystem(config, sys)
+	report := BiosSystemReport{System: sys, Core: info.Core, Status: "ok", Checks: []BiosCheck{}}
+	for _, req := range info.Bios {
+		c := checkBiosRequirement(req)
+		report.Checks = append(report.Checks, c)
+		if c.Required && (c.Status == "missing" || c.Status == "bad") && report.Status != "missing" {
+			report.Status = c.Status
+		}
+	}
+	// 런처에 넘길 BIOS: 필요한 것 우선, 없으면 있는 선택 BIOS 중 첫 번째
+	for _, required := range []bool{true, false} {
+		for _, c := range report.Checks {
+			if report.BiosUrl == "" && c.Required == required && c.File != "" && c.Status != "bad" {
+				report.BiosUrl = "/data/bios/" + c.File
+			}
+		}
+	}
+	return report
+}
+
+func buildBiosReport() BiosReport {
+	config := loadConfigFromHTML()
+	report := BiosReport{Systems: []BiosSystemReport{}, Unknown: []string{}}
+	for _, sys := range librarySystems(config) {
+		report.Systems = append(report.Systems, biosSystemReport(config, sys))
+	}
+
+	known := make(map[string]bool)
+	for _, f := range knownBiosFiles() {
+		known[f.Name] = true
+	}
+	entries, _ := os.ReadDir(biosDir)
+	for _, e := range entries {
+		if !e.IsDir() && !known[e.Name()] {
+			report.Unknown = append(report.Unknown, e.Name())
+		}
+	}
+	return report
+}
+
+// GET  /api/bios            전체 시스템 검사 결과 + 목록에 없는 파일
+// GET  /api/bios?sys=psx    한 시스템 (런처가 EJS_biosUrl 을 정할 때 사용)
+// POST /api/bios/upload?name=scph5501.bin   (본문: 파일, 관리 API)
+func handleBios(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/bios/upload" {
+		handleBiosUpload(w, r)
+		return
+	}
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if sys := r.URL.Query().Get("sys"); sys != "" {
+		json.NewEncoder(w).Encode(biosSystemReport(loadConfigFromHTML(), filepath.Base(sys)))
+		return
+	}
+	json.NewEncoder(w).Encode(buildBiosReport())
+}
+
+func handleBiosUpload(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	if r.Method != "POST" && r.Method != "PUT" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	name := r.URL.Query().Get("name")
+	if !validRomName(name) {
+		http.Error(w, "Invalid name", 400)
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBiosBytes))
+	if err != nil {
+		writeUploadError(w, err, 400)
+		return
+	}
+	if len(data) == 0 {
+		http.Error(w, "Empty body", 400)
+		return
+	}
+
+	// 알려진 파일이면 덤프 검사. ?force=1 이면 맞지 않아도 저장
+	sum := md5.Sum(data)
+	hash := hex.EncodeToString(sum[:])
+	if r.URL.Query().Get("force") != "1" {
+		for _, f := range knownBiosFiles() {
+			if f.Name != name {
+				continue
+			}
+			if (f.Size > 0 && int64(len(data)) != f.Size) || (len(f.MD5) > 0 && !containsFold(f.MD5, hash)) {
+				http.Error(w, "알려진 "+name+" 덤프와 일치하지 않습니다 (md5 "+hash+")", http.StatusUnprocessableEntity)
+				return
+			}
+		}
+	}
+
+	if err := os.MkdirAll(biosDir, 0755); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	if err := writeFileAtomic(filepath.Join(biosDir, name), data, false); err != nil {
+		logger("bios").Error("BIOS 저장 실패", "name", name, "err", err)
+		http.Error(w, "Save failed", 500)
+		return
+	}
+	logger("bios").Info("BIOS 업로드", "name", name, "md5", hash, "bytes", len(data))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"name": name, "md5": hash, "size": len(data)})
+}
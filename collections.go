@@ -0,0 +1,389 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	collectionsFile     = "./data/collections.json"
+	favoritesCollection = "favorites" // 기존 bookmark.json 을 가져온 기본 컬렉션 ID
+)
+
+// [컬렉션] 동적 규칙: field 는 /api/roms 검색 파라미터 이름 (system, genre, players, year, verified, hasSave, q)
+type CollectionRule struct {
+	Field string `json:"field"`
+	Value string `json:"value"`
+}
+
+type Collection struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Order       int              `json:"order"`
+	Items       []BookmarkItem   `json:"items"`
+	Rules       []CollectionRule `json:"rules,omitempty"`
+	Created     int64            `json:"created"`
+	Updated     int64            `json:"updated"`
+}
+
+// [API 응답] 목록에서는 개수만, 단건 조회에서는 규칙까지 반영한 롬 목록을 포함
+type CollectionView struct {
+	Collection
+	Count int        `json:"count"`
+	Roms  []RomEntry `json:"roms,omitempty"`
+}
+
+var collectionsMu sync.Mutex
+
+var collectionRuleFields = map[string]bool{
+	"system": true, "genre": true, "players": true, "year": true,
+	"verified": true, "hasSave": true, "q": true,
+}
+
+// 여러 번 써서 OR 로 묶을 수 있는 필드 (검색 조건이 쉼표 목록을 받는 필드만)
+var collectionListFields = map[string]bool{"system": true, "genre": true}
+
+// collectionsMu 잠금 상태에서 호출. 파일이 없으면 즐겨찾기를 기본 컬렉션으로 가져옴
+func loadCollections() ([]Collection, error) {
+	cols := []Collection{}
+	found, err := collectionsStore.Load(&cols)
+	if err != nil || found {
+		return cols, err
+	}
+	bookmarks, err := loadBookmarks()
+	if err != nil {
+		return cols, err
+	}
+	if len(bookmarks) > 0 {
+		cols = append(cols, favoritesFromBookmarks(bookmarks))
+		return cols, saveCollections(cols)
+	}
+	return cols, nil
+}
+
+func saveCollections(cols []Collection) error {
+	sort.SliceStable(cols, func(i, j int) bool { return cols[i].Order < cols[j].Order })
+	return collectionsStore.Save(cols)
+}
+
+func favoritesFromBookmarks(bookmarks []BookmarkItem) Collection {
+	now := time.Now().Unix()
+	return Collection{
+		ID:      favoritesCollection,
+		Name:    "Favorites",
+		Items:   append([]BookmarkItem{}, bookmarks...),
+		Created: now,
+		Updated: now,
+	}
+}
+
+func findCollection(cols []Collection, id string) int {
+	for i := range cols {
+		if cols[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func validateCollection(c *Collection) error {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" {
+		return fmt.Errorf("Missing name")
+	}
+	for _, rule := range c.Rules {
+		if !collectionRuleFields[rule.Field] {
+			return fmt.Errorf("Unknown rule field: %s", rule.Field)
+		}
+	}
+	if _, err := rulesToQuery(c.Rules); err != nil {
+		return err
+	}
+	if c.Items == nil {
+		c.Items = []BookmarkItem{}
+	}
+	return nil
+}
+
+// 규칙 목록 → 검색 조건. system/genre 는 여러 번 나오면 OR (쉼표 목록), 나머지 필드는 한 번만
+func rulesToQuery(rules []CollectionRule) (RomQuery, error) {
+	v := url.Values{}
+	for _, rule := range rules {
+		prev, dup := v[rule.Field]
+		switch {
+		case !dup:
+			v.Set(rule.Field, rule.Value)
+		case collectionListFields[rule.Field]:
+			v.Set(rule.Field, prev[0]+","+rule.Value)
+		default:
+			return RomQuery{}, fmt.Errorf("Duplicate rule field: %s", rule.Field)
+		}
+	}
+	return parseRomQueryValues(v)
+}
+
+// 고정 항목(지정 순서) + 규칙에 맞는 롬(중복 제외)
+func resolveCollection(c Collection) []RomEntry {
+	library := getLibraryIndex()
+	byKey := make(map[string]RomEntry, len(library.entries))
+	for _, e := range library.entries {
+		byKey[e.System+"/"+e.Name] = e
+	}
+
+	seen := make(map[string]bool)
+	roms := []RomEntry{}
+	for _, item := range c.Items {
+		key := item.System + "/" + item.Rom
+		if e, ok := byKey[key]; ok && !seen[key] {
+			seen[key] = true
+			roms = append(roms, e)
+		}
+	}
+	if len(c.Rules) > 0 {
+		query, err := rulesToQuery(c.Rules)
+		if err != nil {
+			return roms
+		}
+		for _, e := range library.search(query) {
+			key := e.System + "/" + e.Name
+			if !seen[key] {
+				seen[key] = true
+				roms = append(roms, e)
+			}
+		}
+	}
+	return roms
+}
+
+// /api/collections, /api/collections/<id>, /api/collections/<id>/items, /api/collections/import-bookmarks
+func handleCollections(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/collections"), "/")
+	parts := strings.Split(path, "/")
+
+	switch {
+	case path == "":
+		handleCollectionList(w, r)
+	case path == "import-bookmarks":
+		handleCollectionImport(w, r)
+	case len(parts) == 1:
+		handleCollectionItem(w, r, parts[0])
+	case len(parts) == 2 && parts[1] == "items":
+		handleCollectionMembers(w, r, parts[0])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func handleCollectionList(w http.ResponseWriter, r *http.Request) {
+	collectionsMu.Lock()
+	defer collectionsMu.Unlock()
+	cols, err := loadCollections()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		views := []CollectionView{}
+		for _, c := range cols {
+			views = append(views, CollectionView{Collection: c, Count: len(resolveCollection(c))})
+		}
+		writeJSONWithETag(w, r, views)
+
+	case "POST":
+		var c Collection
+		if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+			http.Error(w, "Invalid JSON", 400)
+			return
+		}
+		if err := validateCollection(&c); err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		c.ID = newID()
+		c.Created = time.Now().Unix()
+		c.Updated = c.Created
+		if c.Order == 0 {
+			c.Order = len(cols) + 1
+		}
+		cols = append(cols, c)
+		if err := saveCollections(cols); err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(201)
+		json.NewEncoder(w).Encode(c)
+
+	default:
+		http.Error(w, "Method not allowed", 405)
+	}
+}
+
+func handleCollectionItem(w http.ResponseWriter, r *http.Request, id string) {
+	collectionsMu.Lock()
+	defer collectionsMu.Unlock()
+	cols, err := loadCollections()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	i := findCollection(cols, id)
+	if i < 0 {
+		http.Error(w, "Not found", 404)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		roms := resolveCollection(cols[i])
+		if r.URL.Query().Get("format") == "html" {
+			writeCollectionHTML(w, cols[i], roms)
+			return
+		}
+		writeJSONWithETag(w, r, CollectionView{Collection: cols[i], Count: len(roms), Roms: roms})
+
+	case "PUT":
+		// 전체 교체: items 순서가 곧 표시 순서
+		var c Collection
+		if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+			http.Error(w, "Invalid JSON", 400)
+			return
+		}
+		if err := validateCollection(&c); err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		c.ID = cols[i].ID
+		c.Created = cols[i].Created
+		c.Updated = time.Now().Unix()
+		cols[i] = c
+		if err := saveCollections(cols); err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(c)
+
+	case "DELETE":
+		cols = append(cols[:i], cols[i+1:]...)
+		if err := saveCollections(cols); err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		w.WriteHeader(200)
+
+	default:
+		http.Error(w, "Method not allowed", 405)
+	}
+}
+
+// POST/DELETE /api/collections/<id>/items  {system, rom}
+func handleCollectionMembers(w http.ResponseWriter, r *http.Request, id string) {
+	var item BookmarkItem
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil || item.System == "" || item.Rom == "" {
+		http.Error(w, "Invalid JSON", 400)
+		return
+	}
+
+	collectionsMu.Lock()
+	defer collectionsMu.Unlock()
+	cols, err := loadCollections()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	i := findCollection(cols, id)
+	if i < 0 {
+		http.Error(w, "Not found", 404)
+		return
+	}
+
+	items := []BookmarkItem{}
+	for _, b := range cols[i].Items {
+		if !(b.System == item.System && b.Rom == item.Rom) {
+			items = append(items, b)
+		}
+	}
+	switch r.Method {
+	case "POST":
+		items = append(items, item)
+	case "DELETE":
+	default:
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	cols[i].Items = items
+	cols[i].Updated = time.Now().Unix()
+	if err := saveCollections(cols); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	w.WriteHeader(200)
+}
+
+// POST /api/collections/import-bookmarks: bookmark.json 내용으로 Favorites 컬렉션을 (재)생성
+func handleCollectionImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	collectionsMu.Lock()
+	defer collectionsMu.Unlock()
+	cols, err := loadCollections()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	bookmarks, err := loadBookmarks()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	fav := favoritesFromBookmarks(bookmarks)
+	if i := findCollection(cols, favoritesCollection); i >= 0 {
+		fav.Name = cols[i].Name
+		fav.Description = cols[i].Description
+		fav.Order = cols[i].Order
+		fav.Rules = cols[i].Rules
+		fav.Created = cols[i].Created
+		cols[i] = fav
+	} else {
+		cols = append([]Collection{fav}, cols...)
+	}
+	if err := saveCollections(cols); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(fav)
+}
+
+func writeCollectionHTML(w http.ResponseWriter, c Collection, roms []RomEntry) {
+	var sb strings.Builder
+	if len(roms) == 0 {
+		sb.WriteString(`<div style="text-align:center; padding:50px; color:#aaa;">컬렉션이 비어 있습니다.</div>`)
+	} else {
+		sb.WriteString(fmt.Sprintf(`<div class="category"><div class="category-title" style="border-left-color: #5C6BC0;">%s <span class="game-count">(%d)</span></div>`, html.EscapeString(c.Name), len(roms)))
+		if c.Description != "" {
+			sb.WriteString(fmt.Sprintf(`<div class="collection-desc">%s</div>`, html.EscapeString(c.Description)))
+		}
+		sb.WriteString(`<div class="rom-grid">`)
+		for _, rom := range roms {
+			writeCardHTML(&sb, rom.System, rom.Name, rom.Size)
+		}
+		sb.WriteString(`</div></div>`)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(sb.String()))
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRulesToQuery(t *testing.T) {
+	yes := true
+	tests := []struct {
+		name    string
+		rules   []CollectionRule
+		want    RomQuery
+		wantErr bool
+	}{
+		{"empty", nil, RomQuery{}, false},
+		{"single system", []CollectionRule{{"system", "snes"}}, RomQuery{Systems: []string{"snes"}}, false},
+		{"systems are ORed", []CollectionRule{{"system", "snes"}, {"system", "nes"}}, RomQuery{Systems: []string{"snes", "nes"}}, false},
+		{"genres are ORed", []CollectionRule{{"genre", "RPG"}, {"genre", "Action"}}, RomQuery{Genres: []string{"RPG", "Action"}}, false},
+		{"mixed fields", []CollectionRule{{"system", "psx"}, {"players", "2"}, {"year", "1995-1999"}, {"hasSave", "true"}},
+			RomQuery{Systems: []string{"psx"}, MinPlayers: 2, YearFrom: 1995, YearTo: 1999, HasSave: &yes}, false},
+		{"duplicate numeric field", []CollectionRule{{"players", "2"}, {"players", "4"}}, RomQuery{}, true},
+		{"duplicate year", []CollectionRule{{"year", "1995"}, {"year", "1996"}}, RomQuery{}, true},
+		{"invalid players", []CollectionRule{{"players", "two"}}, RomQuery{}, true},
+	}
+	for _, tt := range tests {
+		got, err := rulesToQuery(tt.rules)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: rulesToQuery error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: rulesToQuery = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
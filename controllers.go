@@ -0,0 +1,393 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// [컨트롤러 프로필] 게임패드(브라우저의 Gamepad.id)와 시스템별 버튼 배치를 서버에 보관해 기기 간 공유
+// 런처는 게임 시작 시 연결된 게임패드에 맞는 프로필을 받아 EJS_defaultControls 위에 덮어쓴다
+const controllersFile = "./data/controllers.json"
+
+var controllersStore = &jsonStore{path: controllersFile}
+
+var controllersMu sync.Mutex
+
+type ControllerProfile struct {
+	ID       string             `json:"id"`
+	Name     string             `json:"name"`
+	Gamepad  string             `json:"gamepad"`          // Gamepad.id (예: "8BitDo SN30 Pro (STANDARD GAMEPAD Vendor: 2dc8 Product: 6101)")
+	System   string             `json:"system,omitempty"` // 비어 있으면 모든 시스템
+	Controls map[int]ControlMap `json:"controls"`         // 플레이어 → 버튼 번호 → 배치
+	Created  int64              `json:"created"`
+	Updated  int64              `json:"updated"`
+}
+
+// 내보내기 파일 형식
+type ControllerExport struct {
+	Version  int                 `json:"version"`
+	Exported int64               `json:"exported"`
+	Profiles []ControllerProfile `json:"profiles"`
+}
+
+// 브라우저마다 Gamepad.id 형식이 달라 제조사/제품 번호로도 비교
+// Chrome: "... (Vendor: 2dc8 Product: 6101)", Firefox: "2dc8-6101-8BitDo SN30 Pro"
+var (
+	reGamepadChrome  = regexp.MustCompile(`(?i)vendor:\s*([0-9a-f]{1,4})\s+product:\s*([0-9a-f]{1,4})`)
+	reGamepadFirefox = regexp.MustCompile(`(?i)^([0-9a-f]{1,4})-([0-9a-f]{1,4})-`)
+)
+
+func gamepadVendorProduct(id string) string {
+	m := reGamepadChrome.FindStringSubmatch(id)
+	if m == nil {
+		m = reGamepadFirefox.FindStringSubmatch(id)
+	}
+	if m == nil {
+		return ""
+	}
+	pad := func(s string) string { return strings.Repeat("0", 4-len(s)) + strings.ToLower(s) }
+	return pad(m[1]) + ":" + pad(m[2])
+}
+
+// 게임패드 이름 (괄호 안의 부가 정보와 Firefox 의 번호 접두어 제외)
+func gamepadName(id string) string {
+	id = reGamepadFirefox.ReplaceAllString(id, "")
+	if i := strings.Index(id, "("); i > 0 {
+		id = id[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(id))
+}
+
+// 0: 다른 게임패드, 1: 이름 일부 일치, 2: 제조사/제품 번호 일치, 3: 정확히 일치
+func gamepadMatchLevel(profile, device string) int {
+	switch {
+	case profile == "" || device == "":
+		return 0
+	case strings.EqualFold(profile, device):
+		return 3
+	}
+	if vp := gamepadVendorProduct(profile); vp != "" && vp == gamepadVendorProduct(device) {
+		return 2
+	}
+	p, d := gamepadName(profile), gamepadName(device)
+	if p != "" && d != "" && (strings.Contains(d, p) || strings.Contains(p, d)) {
+		return 1
+	}
+	return 0
+}
+
+// 게임패드와 시스템에 가장 잘 맞는 프로필 (시스템을 지정한 프로필 → 게임패드가 더 정확히 맞는 프로필 → 최근 수정 순)
+func matchControllerProfile(profiles []ControllerProfile, gamepad, sys string) (ControllerProfile, bool) {
+	best, bestScore := ControllerProfile{}, 0
+	for _, p := range profiles {
+		if p.System != "" && p.System != sys {
+			continue
+		}
+		level := gamepadMatchLevel(p.Gamepad, gamepad)
+		if level == 0 {
+			continue
+		}
+		score := level
+		if p.System != "" {
+			score += 10
+		}
+		if score > bestScore || (score == bestScore && p.Updated > best.Updated) {
+			best, bestScore = p, score
+		}
+	}
+	return best, bestScore > 0
+}
+
+// controllersMu 잠금 상태에서 호출
+func loadControllerProfiles() ([]ControllerProfile, error) {
+	profiles := []ControllerProfile{}
+	_, err := controllersStore.Load(&profiles)
+	return profiles, err
+}
+
+// controllersMu 를 잡고 프로필 목록을 읽음. ok 이면 호출한 쪽에서 controllersMu.Unlock()
+func lockControllerProfiles(w http.ResponseWriter) ([]ControllerProfile, bool) {
+	controllersMu.Lock()
+	profiles, err := loadControllerProfiles()
+	if err != nil {
+		controllersMu.Unlock()
+		http.Error(w, err.Error(), 500)
+		return nil, false
+	}
+	return profiles, true
+}
+
+// 요청 본문의 프로필 하나를 읽고 검사 (잠금 밖에서 호출)
+func decodeControllerProfile(w http.ResponseWriter, r *http.Request) (ControllerProfile, bool) {
+	var p ControllerProfile
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&p); err != nil {
+		http.Error(w, "Invalid JSON", 400)
+		return p, false
+	}
+	if err := validateControllerProfile(&p); err != nil {
+		http.Error(w, err.Error(), 400)
+		return p, false
+	}
+	return p, true
+}
+
+func validateControllerProfile(p *ControllerProfile) error {
+	p.Name = strings.TrimSpace(p.Name)
+	p.Gamepad = strings.TrimSpace(p.Gamepad)
+	if p.Gamepad == "" {
+		return fmt.Errorf("gamepad is required")
+	}
+	if p.Name == "" {
+		p.Name = p.Gamepad
+	}
+	if p.System != "" && !validRomName(p.System) {
+		return fmt.Errorf("invalid system")
+	}
+	if len(p.Controls) == 0 {
+		return fmt.Errorf("controls is required")
+	}
+	for player := range p.Controls {
+		if player < 0 || player > 3 {
+			return fmt.Errorf("invalid player: %d", player)
+		}
+	}
+	return nil
+}
+
+func findControllerProfile(profiles []ControllerProfile, id string) int {
+	for i, p := range profiles {
+		if p.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// GET    /api/controllers                       목록
+// POST   /api/controllers                       생성 {name, gamepad, system, controls}
+// GET    /api/controllers/match?gamepad=&sys=    런처용: 가장 잘 맞는 프로필 (없으면 404)
+// GET    /api/controllers/export (?id=)          내보내기 (파일 다운로드)
+// POST   /api/controllers/import                가져오기 (내보내기 파일 또는 프로필 배열)
+// GET / PUT / DELETE /api/controllers/<id>
+// 변경(생성/수정/삭제/가져오기)은 설정과 같이 관리자 토큰이 필요하다. 본문은 잠금을 잡기 전에 읽고 검사한다
+func handleControllers(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/controllers"), "/")
+	if r.Method != "GET" && !requireAdmin(w, r) {
+		return
+	}
+
+	switch path {
+	case "":
+		handleControllerList(w, r)
+	case "match":
+		handleControllerMatch(w, r)
+	case "export":
+		handleControllerExport(w, r)
+	case "import":
+		handleControllerImport(w, r)
+	default:
+		handleControllerItem(w, r, path)
+	}
+}
+
+func handleControllerMatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	profiles, ok := lockControllerProfiles(w)
+	if !ok {
+		return
+	}
+	controllersMu.Unlock()
+	p, ok := matchControllerProfile(profiles, r.URL.Query().Get("gamepad"), r.URL.Query().Get("sys"))
+	if !ok {
+		http.Error(w, "No matching profile", 404)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p)
+}
+
+func handleControllerList(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		profiles, ok := lockControllerProfiles(w)
+		if !ok {
+			return
+		}
+		controllersMu.Unlock()
+		writeJSONWithETag(w, r, profiles)
+
+	case "POST":
+		p, ok := decodeControllerProfile(w, r)
+		if !ok {
+			return
+		}
+		profiles, ok := lockControllerProfiles(w)
+		if !ok {
+			return
+		}
+		defer controllersMu.Unlock()
+		p.ID = newID()
+		p.Created = time.Now().Unix()
+		p.Updated = p.Created
+		if err := controllersStore.Save(append(profiles, p)); err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		logger("controllers").Info("컨트롤러 프로필 생성", "id", p.ID, "gamepad", p.Gamepad, "sys", p.System)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(201)
+		json.NewEncoder(w).Encode(p)
+
+	default:
+		http.Error(w, "Method not allowed", 405)
+	}
+}
+
+func handleControllerItem(w http.ResponseWriter, r *http.Request, id string) {
+	var p ControllerProfile
+	switch r.Method {
+	case "GET", "DELETE":
+	case "PUT":
+		var ok bool
+		if p, ok = decodeControllerProfile(w, r); !ok {
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+
+	profiles, ok := lockControllerProfiles(w)
+	if !ok {
+		return
+	}
+	defer controllersMu.Unlock()
+	i := findControllerProfile(profiles, id)
+	if i < 0 {
+		http.Error(w, "Not found", 404)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(profiles[i])
+
+	case "PUT":
+		p.ID, p.Created, p.Updated = profiles[i].ID, profiles[i].Created, time.Now().Unix()
+		profiles[i] = p
+		if err := controllersStore.Save(profiles); err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(p)
+
+	case "DELETE":
+		if err := controllersStore.Save(append(profiles[:i], profiles[i+1:]...)); err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		logger("controllers").Info("컨트롤러 프로필 삭제", "id", id)
+		w.WriteHeader(200)
+	}
+}
+
+func handleControllerExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	profiles, ok := lockControllerProfiles(w)
+	if !ok {
+		return
+	}
+	controllersMu.Unlock()
+	export := ControllerExport{Version: 1, Exported: time.Now().Unix(), Profiles: profiles}
+	if id := r.URL.Query().Get("id"); id != "" {
+		i := findControllerProfile(profiles, id)
+		if i < 0 {
+			http.Error(w, "Not found", 404)
+			return
+		}
+		export.Profiles = profiles[i : i+1]
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="controller-profiles.json"`)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(export)
+}
+
+// 같은 게임패드 + 시스템 + 이름의 프로필은 덮어쓰고, 나머지는 새 ID 로 추가
+func handleControllerImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	var raw json.RawMessage
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<20)).Decode(&raw); err != nil {
+		http.Error(w, "Invalid JSON", 400)
+		return
+	}
+	var incoming []ControllerProfile
+	if err := json.Unmarshal(raw, &incoming); err != nil {
+		var export ControllerExport
+		if err := json.Unmarshal(raw, &export); err != nil {
+			http.Error(w, "Invalid JSON", 400)
+			return
+		}
+		incoming = export.Profiles
+	}
+	for i := range incoming {
+		if err := validateControllerProfile(&incoming[i]); err != nil {
+			http.Error(w, fmt.Sprintf("%s: %v", incoming[i].Name, err), 400)
+			return
+		}
+	}
+
+	profiles, ok := lockControllerProfiles(w)
+	if !ok {
+		return
+	}
+	defer controllersMu.Unlock()
+	now := time.Now().Unix()
+	added, replaced := 0, 0
+	for _, p := range incoming {
+		p.Updated = now
+		existing := -1
+		for i, q := range profiles {
+			if q.Gamepad == p.Gamepad && q.System == p.System && q.Name == p.Name {
+				existing = i
+				break
+			}
+		}
+		if existing >= 0 {
+			p.ID, p.Created = profiles[existing].ID, profiles[existing].Created
+			profiles[existing] = p
+			replaced++
+			continue
+		}
+		p.ID = newID()
+		if p.Created == 0 {
+			p.Created = now
+		}
+		profiles = append(profiles, p)
+		added++
+	}
+	if err := controllersStore.Save(profiles); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	logger("controllers").Info("컨트롤러 프로필 가져오기", "added", added, "replaced", replaced)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"added": added, "replaced": replaced})
+}
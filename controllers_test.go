@@ -0,0 +1,77 @@
+package main
+
+import "testing"
+
+const (
+	chromeSN30  = "8BitDo SN30 Pro (STANDARD GAMEPAD Vendor: 2dc8 Product: 6101)"
+	firefoxSN30 = "2dc8-6101-8BitDo SN30 Pro"
+	chromeXbox  = "Xbox Wireless Controller (STANDARD GAMEPAD Vendor: 045e Product: 0b13)"
+)
+
+func TestGamepadVendorProduct(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{chromeSN30, "2dc8:6101"},
+		{firefoxSN30, "2dc8:6101"},
+		{"Pad (Vendor: 45E Product: B13)", "045e:0b13"},
+		{"5e-b13-Pad", "005e:0b13"},
+		{"8BitDo SN30 Pro", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := gamepadVendorProduct(tt.id); got != tt.want {
+			t.Errorf("gamepadVendorProduct(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestGamepadMatchLevel(t *testing.T) {
+	tests := []struct {
+		profile, device string
+		want            int
+	}{
+		{chromeSN30, chromeSN30, 3},
+		{chromeSN30, "8bitdo sn30 pro (standard gamepad vendor: 2dc8 product: 6101)", 3},
+		{chromeSN30, firefoxSN30, 2},
+		{"8BitDo SN30 Pro", chromeSN30, 1},
+		{"8BitDo", firefoxSN30, 1},
+		{chromeSN30, chromeXbox, 0},
+		{"", chromeSN30, 0},
+		{chromeSN30, "", 0},
+	}
+	for _, tt := range tests {
+		if got := gamepadMatchLevel(tt.profile, tt.device); got != tt.want {
+			t.Errorf("gamepadMatchLevel(%q, %q) = %d, want %d", tt.profile, tt.device, got, tt.want)
+		}
+	}
+}
+
+func TestMatchControllerProfile(t *testing.T) {
+	profiles := []ControllerProfile{
+		{ID: "any-name", Gamepad: "8BitDo SN30 Pro", Updated: 5},
+		{ID: "any-exact", Gamepad: chromeSN30, Updated: 1},
+		{ID: "snes-vp", Gamepad: firefoxSN30, System: "snes", Updated: 2},
+		{ID: "nes-exact", Gamepad: chromeSN30, System: "nes", Updated: 3},
+		{ID: "xbox", Gamepad: chromeXbox, Updated: 4},
+		{ID: "xbox-newer", Gamepad: chromeXbox, Updated: 6},
+	}
+	tests := []struct {
+		gamepad, sys string
+		want         string
+	}{
+		{chromeSN30, "snes", "snes-vp"},
+		{chromeSN30, "nes", "nes-exact"},
+		{chromeSN30, "gba", "any-exact"},
+		{"8BitDo SN30 Pro (usb)", "gba", "any-name"},
+		{chromeXbox, "snes", "xbox-newer"},
+		{"Unknown Pad", "snes", ""},
+	}
+	for _, tt := range tests {
+		p, ok := matchControllerProfile(profiles, tt.gamepad, tt.sys)
+		if p.ID != tt.want || ok != (tt.want != "") {
+			t.Errorf("matchControllerProfile(%q, %q) = %q, %v; want %q", tt.gamepad, tt.sys, p.ID, ok, tt.want)
+		}
+	}
+}
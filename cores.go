@@ -0,0 +1,280 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// [코어 목록] EmulatorJS 시스템별 코어, 폴더 별칭, 기본 확장자, BIOS, 스레드 지원, 기본 조작 설정
+// 롬 폴더 이름은 시스템 이름이나 별칭으로 코어를 찾는다. index.html 의 coreMap 은 폴더별로 코어를 바꿀 때만 쓰며,
+// 어느 쪽에도 없는 폴더는 실행하지 않고 알 수 없는 폴더로 보고한다
+type CoreSystem struct {
+	System     string            `json:"system"`
+	Name       string            `json:"name"`
+	Core       string            `json:"core"`
+	Aliases    []string          `json:"aliases,omitempty"`
+	Extensions []string          `json:"extensions"`
+	Bios       []BiosRequirement `json:"bios,omitempty"`
+	Threads    bool              `json:"threads"`  // 스레드 빌드(-thread-wasm.data) 사용
+	Controls   string            `json:"controls"` // controlPresets 이름
+}
+
+var (
+	neoGeoBios = BiosRequirement{Description: "Neo Geo BIOS", Files: []BiosFile{{Name: "neogeo.zip"}}}
+	psxBios    = BiosRequirement{Description: "PlayStation BIOS", Required: true, Files: []BiosFile{
+		{Name: "scph5501.bin", Size: 524288, MD5: []string{"490f666e1afb15b7362b406ed1cea246"}},
+		{Name: "scph5500.bin", Size: 524288, MD5: []string{"8dd7d5296a650fac7319bce665a6a53c"}},
+		{Name: "scph5502.bin", Size: 524288, MD5: []string{"32736f17079d0b2b7024407c39bd3050"}},
+		{Name: "scph1001.bin", Size: 524288, MD5: []string{"924e392ed05558ffdb115408c263dccf"}},
+	}}
+	archiveExts = []string{".zip", ".7z"}
+	discExts    = []string{".cue", ".chd", ".iso", ".m3u"}
+)
+
+func withArchives(list ...string) []string {
+	return append(list, archiveExts...)
+}
+
+func requiredBios(req BiosRequirement) BiosRequirement {
+	req.Required = true
+	return req
+}
+
+var coreRegistry = []CoreSystem{
+	{System: "arcade", Name: "Arcade (FinalBurn Neo)", Core: "fbneo", Aliases: []string{"fbneo", "fba"}, Extensions: withArchives(), Threads: true, Controls: "standard"},
+	{System: "neogeo", Name: "Neo Geo", Core: "fbneo", Extensions: withArchives(), Bios: []BiosRequirement{requiredBios(neoGeoBios)}, Threads: true, Controls: "shoulders"},
+	{System: "mame", Name: "Arcade (MAME 2003-Plus)", Core: "mame2003_plus", Aliases: []string{"mame2003", "mame2003_plus"}, Extensions: withArchives(),
+		Bios: []BiosRequirement{{Description: "Neo Geo BIOS (Neo Geo 게임용)", Files: neoGeoBios.Files}}, Threads: true, Controls: "arcade"},
+
+	{System: "nes", Name: "Nintendo Entertainment System", Core: "fceumm", Aliases: []string{"fc", "famicom", "fds"}, Extensions: withArchives(".nes", ".fds", ".unf", ".unif"),
+		Bios: []BiosRequirement{{Description: "Famicom Disk System BIOS", Files: []BiosFile{{Name: "disksys.rom", Size: 8192, MD5: []string{"ca30b50f880eb660a320674ed365ef7a"}}}}}, Controls: "standard"},
+	{System: "snes", Name: "Super Nintendo", Core: "snes9x", Aliases: []string{"sfc", "superfamicom"}, Extensions: withArchives(".sfc", ".smc", ".fig", ".swc", ".bs"), Threads: true, Controls: "standard"},
+	{System: "n64", Name: "Nintendo 64", Core: "mupen64plus_next", Extensions: withArchives(".n64", ".z64", ".v64"), Controls: "standard"},
+	{System: "gb", Name: "Game Boy", Core: "gambatte", Aliases: []string{"gameboy"}, Extensions: withArchives(".gb"),
+		Bios: []BiosRequirement{{Description: "Game Boy BIOS", Files: []BiosFile{{Name: "gb_bios.bin", Size: 256}}}}, Controls: "standard"},
+	{System: "gbc", Name: "Game Boy Color", Core: "gambatte", Aliases: []string{"gameboycolor"}, Extensions: withArchives(".gbc", ".gb"),
+		Bios: []BiosRequirement{{Description: "Game Boy Color BIOS", Files: []BiosFile{{Name: "gbc_bios.bin", Size: 2304}}}}, Controls: "standard"},
+	{System: "gba", Name: "Game Boy Advance", Core: "mgba", Aliases: []string{"gameboyadvance"}, Extensions: withArchives(".gba"),
+		Bios: []BiosRequirement{{Description: "Game Boy Advance BIOS", Files: []BiosFile{
+			{Name: "gba_bios.bin", Size: 16384, MD5: []string{"a860e8c0b6d573d191e4ec7db1b1e4f6"}},
+		}}}, Threads: true, Controls: "shoulders"},
+	{System: "nds", Name: "Nintendo DS", Core: "melonds", Extensions: withArchives(".nds"), Bios: []BiosRequirement{
+		{Description: "Nintendo DS ARM7 BIOS", Files: []BiosFile{{Name: "bios7.bin", Size: 16384, MD5: []string{"df692a80a5b1bc90728bc3dfc76cd948"}}}},
+		{Description: "Nintendo DS ARM9 BIOS", Files: []BiosFile{{Name: "bios9.bin", Size: 4096, MD5: []string{"a392174eb3e572fed6447e956bde4b25"}}}},
+		{Description: "Nintendo DS Firmware", Files: []BiosFile{{Name: "firmware.bin"}}},
+	}, Threads: true, Controls: "shoulders"},
+	{System: "vb", Name: "Virtual Boy", Core: "beetle_vb", Aliases: []string{"virtualboy"}, Extensions: withArchives(".vb", ".vboy"), Controls: "standard"},
+
+	{System: "segaMS", Name: "Sega Master System", Core: "genesis_plus_gx", Aliases: []string{"sms", "mastersystem"}, Extensions: withArchives(".sms"), Controls: "standard"},
+	{System: "segaMD", Name: "Sega Mega Drive / Genesis", Core: "genesis_plus_gx", Aliases: []string{"md", "genesis", "megadrive"}, Extensions: withArchives(".md", ".gen", ".smd", ".bin"), Controls: "standard"},
+	{System: "segaGG", Name: "Sega Game Gear", Core: "genesis_plus_gx", Aliases: []string{"gg", "gamegear"}, Extensions: withArchives(".gg"), Controls: "standard"},
+	{System: "segaCD", Name: "Sega CD / Mega-CD", Core: "genesis_plus_gx", Aliases: []string{"scd", "megacd"}, Extensions: discExts,
+		Bios: []BiosRequirement{{Description: "Sega CD BIOS", Required: true, Files: []BiosFile{
+			{Name: "bios_CD_U.bin", Size: 131072}, {Name: "bios_CD_E.bin", Size: 131072}, {Name: "bios_CD_J.bin", Size: 131072},
+		}}}, Controls: "standard"},
+	{System: "sega32x", Name: "Sega 32X", Core: "picodrive", Aliases: []string{"32x"}, Extensions: withArchives(".32x"), Controls: "standard"},
+	{System: "segaSaturn", Name: "Sega Saturn", Core: "yabause", Aliases: []string{"saturn"}, Extensions: discExts,
+		Bios: []BiosRequirement{{Description: "Sega Saturn BIOS", Files: []BiosFile{{Name: "saturn_bios.bin", Size: 524288}}}}, Controls: "standard"},
+
+	// 아타리 롬은 고유 확장자(.a26/.a52/.a78)를 먼저 두고, 흔히 쓰이는 .bin 도 받는다. .bin 은 segaMD/psx 와 겹치므로
+	// 시스템은 확장자가 아니라 폴더(이름/별칭/coreMap)로 정해지며, RetroArch 가져오기에서 코어 폴더 없이 같은 이름의 .bin 이
+	// 여러 시스템에 있으면 모호한 롬으로 건너뛴다
+	{System: "atari2600", Name: "Atari 2600", Core: "stella2014", Aliases: []string{"a26"}, Extensions: withArchives(".a26", ".bin"), Controls: "standard"},
+	{System: "atari5200", Name: "Atari 5200", Core: "a5200", Extensions: withArchives(".a52", ".bin"),
+		Bios: []BiosRequirement{{Description: "Atari 5200 BIOS", Required: true, Files: []BiosFile{{Name: "5200.rom", Size: 2048}}}}, Controls: "standard"},
+	{System: "atari7800", Name: "Atari 7800", Core: "prosystem", Aliases: []string{"a78"}, Extensions: withArchives(".a78", ".bin"),
+		Bios: []BiosRequirement{{Description: "Atari 7800 BIOS", Files: []BiosFile{{Name: "7800 BIOS (U).rom", Size: 4096}}}}, Controls: "standard"},
+	{System: "lynx", Name: "Atari Lynx", Core: "handy", Aliases: []string{"atarilynx"}, Extensions: withArchives(".lnx"),
+		Bios: []BiosRequirement{{Description: "Atari Lynx Boot ROM", Required: true, Files: []BiosFile{{Name: "lynxboot.img", Size: 512}}}}, Controls: "standard"},
+	{System: "jaguar", Name: "Atari Jaguar", Core: "virtualjaguar", Aliases: []string{"atarijaguar"}, Extensions: withArchives(".j64", ".jag"), Controls: "standard"},
+
+	{System: "pce", Name: "PC Engine / TurboGrafx-16", Core: "mednafen_pce", Aliases: []string{"pcengine", "tg16", "turbografx"}, Extensions: append(withArchives(".pce"), discExts...),
+		Bios: []BiosRequirement{{Description: "PC Engine CD System Card (CD 게임용)", Files: []BiosFile{{Name: "syscard3.pce", Size: 262144}}}}, Controls: "standard"},
+	{System: "pcfx", Name: "PC-FX", Core: "mednafen_pcfx", Extensions: discExts,
+		Bios: []BiosRequirement{{Description: "PC-FX BIOS", Required: true, Files: []BiosFile{{Name: "pcfx.rom", Size: 1048576}}}}, Controls: "standard"},
+	{System: "ngp", Name: "Neo Geo Pocket", Core: "mednafen_ngp", Aliases: []string{"ngpc", "neogeopocket"}, Extensions: withArchives(".ngp", ".ngc"), Controls: "standard"},
+	{System: "ws", Name: "WonderSwan", Core: "mednafen_wswan", Aliases: []string{"wsc", "wonderswan"}, Extensions: withArchives(".ws", ".wsc"), Controls: "standard"},
+	{System: "coleco", Name: "ColecoVision", Core: "gearcoleco", Aliases: []string{"colecovision"}, Extensions: withArchives(".col"),
+		Bios: []BiosRequirement{{Description: "ColecoVision BIOS", Required: true, Files: []BiosFile{{Name: "colecovision.rom", Size: 8192}}}}, Controls: "standard"},
+	{System: "3do", Name: "3DO", Core: "opera", Extensions: discExts,
+		Bios: []BiosRequirement{{Description: "3DO BIOS", Required: true, Files: []BiosFile{{Name: "panafz10.bin", Size: 1048576}}}}, Controls: "standard"},
+
+	{System: "psx", Name: "PlayStation", Core: "mednafen_psx_hw", Aliases: []string{"ps1", "playstation"}, Extensions: []string{".cue", ".chd", ".pbp", ".iso", ".img", ".bin", ".m3u"},
+		Bios: []BiosRequirement{psxBios}, Threads: true, Controls: "standard"},
+	{System: "psp", Name: "PlayStation Portable", Core: "ppsspp", Extensions: []string{".iso", ".cso", ".pbp"}, Threads: true, Controls: "shoulders"},
+
+	{System: "c64", Name: "Commodore 64", Core: "vice_x64sc", Aliases: []string{"commodore64"}, Extensions: withArchives(".d64", ".t64", ".prg", ".crt", ".tap"), Controls: "standard"},
+	{System: "amiga", Name: "Commodore Amiga", Core: "puae", Extensions: withArchives(".adf", ".adz", ".dms", ".ipf", ".hdf", ".lha"),
+		Bios: []BiosRequirement{{Description: "Kickstart 1.3 (없으면 내장 AROS 사용)", Files: []BiosFile{{Name: "kick34005.A500", Size: 262144}}}}, Controls: "standard"},
+	{System: "dos", Name: "DOS", Core: "dosbox_pure", Extensions: []string{".zip", ".dosz", ".exe", ".com", ".bat", ".iso", ".cue"}, Controls: "standard"},
+}
+
+// EmulatorJS EJS_defaultControls 의 버튼 하나 (value: 키보드, value2: 게임패드)
+type ControlButton struct {
+	Value  string `json:"value"`
+	Value2 string `json:"value2,omitempty"`
+}
+
+// 플레이어 1 배치. 2~4P 는 EmulatorJS 기본값
+type ControlMap map[int]ControlButton
+
+// 기존 런처의 배치를 그대로 옮김. 시스템마다 1, 9, 10, 11번 버튼(s, a, q, e 키)만 다르다
+func controlPreset(b1, b9, b10, b11 string) ControlMap {
+	return ControlMap{
+		0: {"x", "BUTTON_1"}, 1: {"s", b1}, 2: {"v", "SELECT"}, 3: {"enter", "START"},
+		4: {"up arrow", "LEFT_STICK_Y:-1"}, 5: {"down arrow", "LEFT_STICK_Y:+1"},
+		6: {"left arrow", "LEFT_STICK_X:-1"}, 7: {"right arrow", "LEFT_STICK_X:+1"},
+		8: {"z", "BUTTON_2"}, 9: {"a", b9}, 10: {"q", b10}, 11: {"e", b11},
+		12: {Value: "tab"}, 13: {Value: "r"},
+		16: {"h", "DPAD_RIGHT"}, 17: {"", "DPAD_LEFT"}, 18: {"", "DPAD_DOWN"}, 19: {"", "DPAD_UP"},
+		24: {Value: "1"}, 25: {Value: "2"}, 26: {Value: "3"},
+		27: {"add", "GAMEPAD_16"}, 28: {Value: "space"}, 29: {Value: "subtract"},
+	}
+}
+
+var controlPresets = map[string]ControlMap{
+	"standard":  controlPreset("BUTTON_3", "BUTTON_4", "RIGHT_TOP_SHOULDER", "RIGHT_BOTTOM_SHOULDER"),
+	"arcade":    controlPreset("RIGHT_BOTTOM_SHOULDER", "RIGHT_TOP_SHOULDER", "BUTTON_3", "BUTTON_4"),
+	"shoulders": controlPreset("RIGHT_BOTTOM_SHOULDER", "RIGHT_TOP_SHOULDER", "LEFT_TOP_SHOULDER", "LEFT_BOTTOM_SHOULDER"),
+	"capcom6":   controlPreset("RIGHT_TOP_SHOULDER", "BUTTON_3", "BUTTON_4", "RIGHT_BOTTOM_SHOULDER"), // 6버튼 격투 게임 (기본 설정: mame/SF*)
+}
+
+// EJS_defaultControls 형식 ({0: 1P, 1: {}, 2: {}, 3: {}})
+func defaultControls(preset string) map[int]ControlMap {
+	p, ok := controlPresets[preset]
+	if !ok {
+		p = controlPresets["standard"]
+	}
+	return map[int]ControlMap{0: p, 1: {}, 2: {}, 3: {}}
+}
+
+// 롬 폴더 → 시스템 정보. coreMap 에 있으면 그 코어를 쓰는 첫 시스템의 설정을 빌려 옴
+// 둘 다 없으면 false (알 수 없는 폴더)
+func lookupSystem(config Config, folder string) (CoreSystem, bool) {
+	var found CoreSystem
+	ok := false
+	for _, s := range coreRegistry {
+		if strings.EqualFold(s.System, folder) || containsFold(s.Aliases, folder) {
+			found, ok = s, true
+			break
+		}
+	}
+	if core, mapped := config.Systems[folder]; mapped && (!ok || found.Core != core) {
+		found, ok = CoreSystem{System: folder, Name: folder, Core: core, Controls: "standard"}, true
+		for _, s := range coreRegistry {
+			if s.Core == core {
+				found.Name, found.Extensions, found.Bios, found.Threads = s.Name, s.Extensions, s.Bios, s.Threads
+				break
+			}
+		}
+	}
+	return found, ok
+}
+
+// 시스템 → 코어 이름. 알 수 없는 폴더면 빈 문자열
+func coreForSystem(config Config, sys string) string {
+	if s, ok := lookupSystem(config, sys); ok {
+		return s.Core
+	}
+	return ""
+}
+
+// 롬 폴더와 coreMap 에 있는 시스템 이름 (정렬)
+func librarySystems(config Config) []string {
+	seen := make(map[string]bool)
+	for sys := range config.Systems {
+		seen[sys] = true
+	}
+	entries, _ := os.ReadDir(romsDir)
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			seen[e.Name()] = true
+		}
+	}
+	systems := make([]string, 0, len(seen))
+	for sys := range seen {
+		systems = append(systems, sys)
+	}
+	sort.Strings(systems)
+	return systems
+}
+
+// 코어 동기화 목록에 없는, 사용 중인 시스템의 코어 파일
+func extraCoreFiles(listed []string) []string {
+	have := make(map[string]bool)
+	for _, f := range listed {
+		have[f] = true
+	}
+	config := loadConfigFromHTML()
+	var files []string
+	for _, sys := range librarySystems(config) {
+		s, ok := lookupSystem(config, sys)
+		if !ok {
+			continue
+		}
+		variants := []string{"-wasm.data", "-legacy-wasm.data"}
+		if s.Threads {
+			variants = append(variants, "-thread-wasm.data")
+		}
+		for _, v := range variants {
+			if f := s.Core + v; !have[f] {
+				have[f] = true
+				files = append(files, f)
+			}
+		}
+	}
+	return files
+}
+
+type CoreFolder struct {
+	Folder string `json:"folder"`
+	System string `json:"system,omitempty"`
+	Core   string `json:"core,omitempty"`
+	Known  bool   `json:"known"`
+}
+
+type CoreLaunchInfo struct {
+	CoreSystem
+	Folder          string             `json:"folder"`
+	DefaultControls map[int]ControlMap `json:"defaultControls"`
+}
+
+// GET /api/cores          전체 코어 목록 + 롬 폴더별 코어 (알 수 없는 폴더 포함)
+// GET /api/cores?sys=snes 한 폴더 (런처가 EJS_core, EJS_threads, EJS_defaultControls 를 정할 때 사용)
+func handleCores(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	config := loadConfigFromHTML()
+	w.Header().Set("Content-Type", "application/json")
+
+	if sys := r.URL.Query().Get("sys"); sys != "" {
+		sys = filepath.Base(sys)
+		s, ok := lookupSystem(config, sys)
+		if !ok {
+			w.WriteHeader(404)
+			json.NewEncoder(w).Encode(map[string]string{"folder": sys, "error": "unknown system folder"})
+			return
+		}
+		json.NewEncoder(w).Encode(CoreLaunchInfo{CoreSystem: s, Folder: sys, DefaultControls: defaultControls(s.Controls)})
+		return
+	}
+
+	folders := []CoreFolder{}
+	unknown := []string{}
+	for _, sys := range librarySystems(config) {
+		f := CoreFolder{Folder: sys}
+		if s, ok := lookupSystem(config, sys); ok {
+			f.System, f.Core, f.Known = s.System, s.Core, true
+		} else {
+			unknown = append(unknown, sys)
+		}
+		folders = append(folders, f)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"systems": coreRegistry, "folders": folders, "unknown": unknown})
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// [멀티 디스크] "(Disc 1)" 형식의 디스크 파일을 게임 하나로 묶어 .m3u 재생 목록으로 보여준다
+// 재생 목록이 롬 역할을 하므로 세이브/상태 저장/플레이 기록은 .m3u 이름을 기준으로 한다
+// 재생 목록은 스캔할 때 메모리에만 만들고(가상 재생 목록) /data/roms/<sys>/<이름>.m3u 요청에 내용을 만들어 응답한다.
+// 사용자의 롬 폴더에 실제 파일로 남기는 것은 RETRO_AUTO_PLAYLIST=1 일 때만 한다
+const autoPlaylistEnv = "RETRO_AUTO_PLAYLIST"
+
+func autoPlaylistEnabled() bool {
+	v := strings.ToLower(os.Getenv(autoPlaylistEnv))
+	return v == "1" || v == "true"
+}
+
+var reDiscTag = regexp.MustCompile(`(?i)\s*[\(\[](?:disc|disk|cd)\s*([0-9]+)(?:\s*of\s*[0-9]+)?[\)\]]`)
+
+// 같은 디스크가 여러 형식으로 있을 때 재생 목록에 넣을 우선순위
+var discExtRank = map[string]int{".cue": 5, ".chd": 4, ".pbp": 3, ".iso": 2, ".img": 1, ".bin": 0}
+
+func isPlaylist(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".m3u")
+}
+
+// 재생 목록에 적힌 디스크 파일 (재생 목록이 있는 폴더 기준 상대 경로, 폴더 밖은 무시)
+// 파일이 없으면 스캔 때 만든 가상 재생 목록을 찾음
+func readPlaylist(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		discs, _ := virtualPlaylist(path)
+		return discs
+	}
+	defer f.Close()
+	var discs []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		clean := filepath.ToSlash(filepath.Clean(filepath.FromSlash(line)))
+		if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+			continue
+		}
+		discs = append(discs, clean)
+	}
+	return discs
+}
+
+// 디스크 번호 태그를 뺀 제목과 디스크 번호 ("Game (Disc 2) (USA).chd" → "Game (USA)", 2)
+func splitDiscTag(name string) (string, int, bool) {
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	loc := reDiscTag.FindStringSubmatchIndex(base)
+	if loc == nil {
+		return "", 0, false
+	}
+	num, err := strconv.Atoi(base[loc[2]:loc[3]])
+	if err != nil {
+		return "", 0, false
+	}
+	title := strings.TrimSpace(base[:loc[0]] + base[loc[1]:])
+	return title, num, title != ""
+}
+
+// 가상 재생 목록의 디스크 (p 는 재생 목록이 있을 자리의 경로)
+func virtualPlaylist(p string) ([]string, bool) {
+	romLocations.Lock()
+	defer romLocations.Unlock()
+	for _, playlists := range romLocations.playlists {
+		if discs, ok := playlists[filepath.Clean(p)]; ok {
+			return discs, true
+		}
+	}
+	return nil, false
+}
+
+// 파일로 있지 않고 스캔 때 메모리에 만든 재생 목록인지
+func isVirtualPlaylist(p string) bool {
+	if _, err := os.Stat(p); err == nil {
+		return false
+	}
+	_, ok := virtualPlaylist(p)
+	return ok
+}
+
+// 가상 재생 목록을 파일처럼 응답 (/data/roms/<sys>/<이름>.m3u). 처리했으면 true
+func serveVirtualPlaylist(w http.ResponseWriter, r *http.Request) bool {
+	rest, ok := strings.CutPrefix(r.URL.Path, "/data/roms/")
+	if !ok || !isPlaylist(rest) {
+		return false
+	}
+	sys, rom, ok := strings.Cut(rest, "/")
+	if !ok || strings.Contains(rom, "/") {
+		return false
+	}
+	p, ok := resolveRomPath(sys, rom)
+	if !ok || !isVirtualPlaylist(p) {
+		return false
+	}
+	w.Header().Set("Content-Type", "audio/x-mpegurl; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	io.WriteString(w, strings.Join(readPlaylist(p), "\n")+"\n")
+	return true
+}
+
+// 한 시스템 폴더의 롬 목록에서 디스크 파일을 재생 목록 하나로 묶음
+// 이미 있는 .m3u 가 가리키는 파일은 숨기고, 묶이지 않은 여러 장짜리 게임은 재생 목록 항목을 새로 만든다.
+// 새 재생 목록은 generate 일 때만 파일로 쓰고, 아니면 이름 → 디스크 목록을 함께 돌려준다 (가상 재생 목록)
+func groupMultiDisc(dir string, list []RomInfo, generate bool) ([]RomInfo, map[string][]string) {
+	virtual := make(map[string][]string)
+	hidden := make(map[string]bool)
+	names := make(map[string]bool)
+	for i, r := range list {
+		names[r.Name] = true
+		if !isPlaylist(r.Name) {
+			continue
+		}
+		discs := readPlaylist(filepath.Join(dir, r.Name))
+		var size int64
+		for _, d := range discs {
+			hidden[d] = true
+			size += fileSize(filepath.Join(dir, filepath.FromSlash(d)))
+		}
+		list[i].Size = size
+		list[i].Discs = len(discs)
+	}
+
+	groups := make(map[string]map[int]RomInfo)
+	for _, r := range list {
+		ext := strings.ToLower(filepath.Ext(r.Name))
+		if hidden[r.Name] || isPlaylist(r.Name) {
+			continue
+		}
+		if _, ok := discExtRank[ext]; !ok {
+			continue
+		}
+		title, num, ok := splitDiscTag(r.Name)
+		if !ok {
+			continue
+		}
+		if groups[title] == nil {
+			groups[title] = make(map[int]RomInfo)
+		}
+		if prev, ok := groups[title][num]; !ok || discExtRank[ext] > discExtRank[strings.ToLower(filepath.Ext(prev.Name))] {
+			groups[title][num] = r
+		}
+	}
+
+	for title, discs := range groups {
+		if len(discs) < 2 {
+			continue
+		}
+		playlist := title + ".m3u"
+		if names[playlist] {
+			continue // 같은 이름의 재생 목록이 따로 있으면 사용자가 만든 것을 존중
+		}
+		nums := make([]int, 0, len(discs))
+		for n := range discs {
+			nums = append(nums, n)
+		}
+		sort.Ints(nums)
+
+		var sb strings.Builder
+		var members []string
+		entry := RomInfo{Name: playlist, Discs: len(nums)}
+		for _, n := range nums {
+			d := discs[n]
+			sb.WriteString(d.Name + "\n")
+			members = append(members, d.Name)
+			entry.Size += d.Size
+			if d.ModTime > entry.ModTime {
+				entry.ModTime = d.ModTime
+			}
+		}
+		if generate {
+			if err := writeFileAtomic(filepath.Join(dir, playlist), []byte(sb.String()), false); err != nil {
+				logger("library").Error("재생 목록 생성 실패", "path", filepath.Join(dir, playlist), "err", err)
+				continue
+			}
+			logger("library").Warn("롬 폴더에 재생 목록 생성", "path", filepath.Join(dir, playlist), "discs", len(nums))
+		} else {
+			virtual[playlist] = members
+		}
+		// 묶인 디스크와 같은 제목의 다른 형식 파일도 숨김
+		for _, r := range list {
+			_, isDisc := discExtRank[strings.ToLower(filepath.Ext(r.Name))]
+			if t, _, ok := splitDiscTag(r.Name); ok && isDisc && t == title {
+				hidden[r.Name] = true
+			}
+		}
+		list = append(list, entry)
+		names[playlist] = true
+	}
+
+	result := list[:0]
+	for _, r := range list {
+		if !hidden[r.Name] {
+			result = append(result, r)
+		}
+	}
+	return result, virtual
+}
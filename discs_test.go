@@ -0,0 +1,140 @@
+package main
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitDiscTag(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		num   int
+		ok    bool
+	}{
+		{"Game (Disc 1).chd", "Game", 1, true},
+		{"Game (Disc 2) (USA).chd", "Game (USA)", 2, true},
+		{"Game (USA) (Disc 3).cue", "Game (USA)", 3, true},
+		{"Game [Disk 2].iso", "Game", 2, true},
+		{"Game (CD1).bin", "Game", 1, true},
+		{"Game (disc 2 of 3).pbp", "Game", 2, true},
+		{"Game (Disc 10).chd", "Game", 10, true},
+		{"Game (USA).chd", "", 0, false},
+		{"Game (Discovery).chd", "", 0, false},
+		{"(Disc 1).chd", "", 1, false},
+	}
+	for _, tt := range tests {
+		title, num, ok := splitDiscTag(tt.name)
+		if title != tt.title || num != tt.num || ok != tt.ok {
+			t.Errorf("splitDiscTag(%q) = %q, %d, %v; want %q, %d, %v", tt.name, title, num, ok, tt.title, tt.num, tt.ok)
+		}
+	}
+}
+
+func TestReadPlaylist(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"plain", "Game (Disc 1).chd\nGame (Disc 2).chd\n", []string{"Game (Disc 1).chd", "Game (Disc 2).chd"}},
+		{"bom and comments", "\ufeff#EXTM3U\n\n# comment\nGame (Disc 1).cue\r\n", []string{"Game (Disc 1).cue"}},
+		{"subfolder", "discs/./Game (Disc 1).chd\n", []string{"discs/Game (Disc 1).chd"}},
+		{"outside folder", "../other.chd\n/abs/game.chd\n..\nok.chd\n", []string{"ok.chd"}},
+		{"empty", "", nil},
+	}
+	dir := t.TempDir()
+	for _, tt := range tests {
+		p := filepath.Join(dir, tt.name+".m3u")
+		if err := os.WriteFile(p, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got := readPlaylist(p); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: readPlaylist = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if got := readPlaylist(filepath.Join(dir, "missing.m3u")); got != nil {
+		t.Errorf("missing file: readPlaylist = %q, want nil", got)
+	}
+}
+
+func TestGroupMultiDiscBuildsVirtualPlaylist(t *testing.T) {
+	list := []RomInfo{
+		{Name: "Game (Disc 1) (USA).chd", Size: 10, ModTime: 1},
+		{Name: "Game (Disc 2) (USA).chd", Size: 20, ModTime: 3},
+		{Name: "Game (Disc 2) (USA).bin", Size: 30, ModTime: 2},
+		{Name: "Other.chd", Size: 5},
+	}
+	dir := t.TempDir()
+	got, virtual := groupMultiDisc(dir, list, false)
+
+	want := []RomInfo{{Name: "Other.chd", Size: 5}, {Name: "Game (USA).m3u", Size: 30, ModTime: 3, Discs: 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groupMultiDisc = %+v, want %+v", got, want)
+	}
+	wantDiscs := map[string][]string{"Game (USA).m3u": {"Game (Disc 1) (USA).chd", "Game (Disc 2) (USA).chd"}}
+	if !reflect.DeepEqual(virtual, wantDiscs) {
+		t.Errorf("virtual playlists = %q, want %q", virtual, wantDiscs)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Game (USA).m3u")); err == nil {
+		t.Errorf("playlist was written into the rom folder")
+	}
+}
+
+func TestVirtualPlaylistServedAndTrashed(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv(autoPlaylistEnv, "")
+	writeTestRom(t, "psx", "Game (Disc 1) (USA).chd", "disc one")
+	writeTestRom(t, "psx", "Game (Disc 2) (USA).chd", "disc two")
+	const playlist = "Game (USA).m3u"
+
+	var names []string
+	for _, r := range scanRomLibrary(romsDir)["psx"] {
+		names = append(names, r.Name)
+	}
+	if !reflect.DeepEqual(names, []string{playlist}) {
+		t.Fatalf("psx library = %q, want only the playlist", names)
+	}
+	if _, err := os.Stat(filepath.Join(romsDir, "psx", playlist)); err == nil {
+		t.Fatalf("playlist file was written into the rom folder")
+	}
+	if !romExists("psx", playlist) {
+		t.Fatalf("virtual playlist is not a known rom")
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/data/roms/psx/"+url.PathEscape(playlist), nil)
+	if !serveVirtualPlaylist(w, r) {
+		t.Fatalf("virtual playlist request was not handled")
+	}
+	if body := w.Body.String(); body != "Game (Disc 1) (USA).chd\nGame (Disc 2) (USA).chd\n" {
+		t.Errorf("playlist body = %q", body)
+	}
+	w = httptest.NewRecorder()
+	handleRomFiles(w, httptest.NewRequest("GET", "/api/rom/files?sys=psx&rom="+url.QueryEscape(playlist), nil))
+	if !strings.Contains(w.Body.String(), "Game (Disc 2) (USA).chd") {
+		t.Errorf("rom files = %s, want both discs", w.Body)
+	}
+
+	item, err := moveRomToTrash("psx", playlist, defaultUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if romExists("psx", playlist) || romExists("psx", "Game (Disc 1) (USA).chd") {
+		t.Fatalf("discs are still in the library after trashing")
+	}
+	if _, err := restoreFromTrash(item.ID); err != nil {
+		t.Fatal(err)
+	}
+	if !romExists("psx", playlist) {
+		t.Errorf("virtual playlist is missing after restore")
+	}
+	if _, err := os.Stat(filepath.Join(romsDir, "psx", playlist)); err == nil {
+		t.Errorf("restore wrote a playlist file")
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// [상태 점검] /healthz 는 프로세스가 응답하는지만 (잠금을 기다리지 않음),
+// /readyz 는 게임을 실행할 수 있는 상태인지(에뮬레이터 데이터, 코어, BIOS, 디스크) 확인한다.
+// status 가 fail 인 항목이 하나라도 있으면 503, warn 은 응답 코드에 영향 없음
+const ejsDataDir = "./emulatorjs/data"
+
+type HealthCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // ok / warn / fail
+	Detail string `json:"detail,omitempty"`
+}
+
+type HealthReport struct {
+	Status string        `json:"status"`
+	Uptime int64         `json:"uptime"`
+	Checks []HealthCheck `json:"checks"`
+}
+
+func writeHealthReport(w http.ResponseWriter, checks []HealthCheck) {
+	report := HealthReport{
+		Status: "ok",
+		Uptime: int64(time.Since(serverStartTime).Seconds()),
+		Checks: checks,
+	}
+	for _, c := range checks {
+		if c.Status == "fail" {
+			report.Status = "fail"
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status == "fail" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+// 주요 잠금이 지금 잡혀 있는지 (기다리지 않음). SSR 재생성이나 롬 병합 중에는 잠시 잡혀 있을 수 있으므로 warn
+func tryLockCheck(name string, tryLock func() bool, unlock func()) HealthCheck {
+	if !tryLock() {
+		return HealthCheck{Name: name, Status: "warn", Detail: "busy"}
+	}
+	unlock()
+	return HealthCheck{Name: name, Status: "ok"}
+}
+
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeHealthReport(w, []HealthCheck{
+		{Name: "goroutines", Status: "ok", Detail: fmt.Sprint(runtime.NumGoroutine())},
+	})
+}
+
+func checkFile(name, path string) HealthCheck {
+	info, err := os.Stat(path)
+	if err != nil {
+		return HealthCheck{Name: name, Status: "fail", Detail: err.Error()}
+	}
+	if info.IsDir() || info.Size() == 0 {
+		return HealthCheck{Name: name, Status: "fail", Detail: path + " is empty"}
+	}
+	return HealthCheck{Name: name, Status: "ok"}
+}
+
+// 롬 폴더/coreMap 의 시스템별 코어 파일 (일반/스레드/레거시 빌드 중 하나라도 있으면 실행 가능)
+// 코어를 알 수 없는 폴더는 그 폴더의 게임만 실행되지 않으므로 warn
+func checkCores(config Config) []HealthCheck {
+	var checks []HealthCheck
+	for _, sys := range librarySystems(config) {
+		core := coreForSystem(config, sys)
+		if core == "" {
+			checks = append(checks, HealthCheck{Name: "core:" + sys, Status: "warn", Detail: "unknown system folder"})
+			continue
+		}
+		check := HealthCheck{Name: "core:" + sys, Status: "fail", Detail: core + " not installed"}
+		for _, variant := range []string{"-wasm.data", "-thread-wasm.data", "-legacy-wasm.data", "-thread-legacy-wasm.data"} {
+			if info, err := os.Stat(filepath.Join(ejsDataDir, "cores", core+variant)); err == nil && info.Size() > 0 {
+				check = HealthCheck{Name: "core:" + sys, Status: "ok", Detail: core + variant}
+				break
+			}
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// 롬 폴더별로 필요한 BIOS (cores.go 의 코어 목록 기준). 없으면 해당 시스템 게임만 실행되지 않으므로 warn
+func checkBios(config Config) []HealthCheck {
+	var checks []HealthCheck
+	for _, sys := range librarySystems(config) {
+		report := biosSystemReport(config, sys)
+		if len(report.Checks) == 0 {
+			continue
+		}
+		check := HealthCheck{Name: "bios:" + sys, Status: "ok", Detail: report.BiosUrl}
+		if report.Status != "ok" {
+			check.Status, check.Detail = "warn", "required BIOS "+report.Status
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// 쓰기 확인은 SD 카드에 파일을 만들었다 지우므로, 자주 호출되는 /readyz 에서는 결과를 잠시 재사용
+const dataWritableRecheck = time.Minute
+
+var dataWritable struct {
+	sync.Mutex
+	checked time.Time
+	result  HealthCheck
+}
+
+func checkDataWritable() HealthCheck {
+	dataWritable.Lock()
+	defer dataWritable.Unlock()
+	if time.Since(dataWritable.checked) < dataWritableRecheck {
+		return dataWritable.result
+	}
+	dataWritable.result = probeDataWritable()
+	dataWritable.checked = time.Now()
+	return dataWritable.result
+}
+
+func probeDataWritable() HealthCheck {
+	const name = "data:writable"
+	if err := os.MkdirAll("./data", 0755); err != nil {
+		return HealthCheck{Name: name, Status: "fail", Detail: err.Error()}
+	}
+	f, err := os.CreateTemp("./data", ".readyz-*")
+	if err != nil {
+		return HealthCheck{Name: name, Status: "fail", Detail: err.Error()}
+	}
+	_, err = f.Write([]byte("ok"))
+	f.Close()
+	os.Remove(f.Name())
+	if err != nil {
+		return HealthCheck{Name: name, Status: "fail", Detail: err.Error()}
+	}
+	return HealthCheck{Name: name, Status: "ok"}
+}
+
+func checkDiskFree() HealthCheck {
+	wd, err := os.Getwd()
+	if err != nil {
+		return HealthCheck{Name: "disk:free", Status: "fail", Detail: err.Error()}
+	}
+	free, total := getDiskUsage(wd)
+	detail := fmt.Sprintf("%dMB free of %dMB", free>>20, total>>20)
+	if total > 0 && free < minFreeDiskBytes {
+		return HealthCheck{Name: "disk:free", Status: "fail", Detail: detail}
+	}
+	return HealthCheck{Name: "disk:free", Status: "ok", Detail: detail}
+}
+
+func handleReadyz(w http.ResponseWriter, r *http.Request) {
+	var checks []HealthCheck
+
+	if _, err := os.Stat("index.html"); err != nil {
+		checks = append(checks, HealthCheck{Name: "index.html", Status: "fail", Detail: err.Error()})
+	} else {
+		checks = append(checks, HealthCheck{Name: "index.html", Status: "ok"})
+	}
+
+	checks = append(checks,
+		checkFile("emulatorjs:loader", filepath.Join(ejsDataDir, "loader.js")),
+		checkFile("emulatorjs:version", filepath.Join(ejsDataDir, "version.json")),
+	)
+	config := loadConfigFromHTML()
+	checks = append(checks, checkCores(config)...)
+	checks = append(checks, checkBios(config)...)
+	checks = append(checks,
+		tryLockCheck("lock:indexCache", indexCache.TryRLock, indexCache.RUnlock),
+		tryLockCheck("lock:playSessions", playSessions.TryLock, playSessions.Unlock),
+		tryLockCheck("lock:processing", processingMutex.TryLock, processingMutex.Unlock),
+	)
+
+	// 7z 는 코어 동기화에만 필요하므로 없어도 실행에는 지장 없음
+	if path, err := exec.LookPath("7z"); err != nil {
+		checks = append(checks, HealthCheck{Name: "7z", Status: "warn", Detail: "not found in PATH (core sync disabled)"})
+	} else {
+		checks = append(checks, HealthCheck{Name: "7z", Status: "ok", Detail: path})
+	}
+
+	checks = append(checks, checkDataWritable(), checkDiskFree())
+	writeHealthReport(w, checks)
+}
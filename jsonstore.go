@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// [저장소] data/ 아래의 작은 JSON 상태 파일 공용 저장 계층
+//   - 같은 파일에 대한 읽기-수정-쓰기를 직렬화 (동시 요청 시 갱신 유실 방지)
+//   - 임시 파일 → fsync → rename 으로 원자적 교체 (쓰는 도중 전원이 나가도 이전 내용 유지)
+//   - 직전 세대를 <파일>.bak 으로 보관
+//   - 파싱 실패 시 빈 값으로 덮어쓰지 않고 오류를 돌려줌
+type jsonStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+var (
+	bookmarkStore    = &jsonStore{path: "./data/bookmark.json"}
+	injectLogStore   = &jsonStore{path: "./data/injected.json"}
+	coreSyncStore    = &jsonStore{path: "./data/core_sync.json"}
+	collectionsStore = &jsonStore{path: collectionsFile}
+	playStatsStore   = &jsonStore{path: playStatsFile}
+)
+
+// 파일이 손상되어 읽을 수 없을 때의 오류 (원본은 그대로 둔다)
+type storeCorruptError struct {
+	Path string
+	Err  error
+}
+
+func (e *storeCorruptError) Error() string {
+	return fmt.Sprintf("%s 파싱 실패 (백업: %s.bak): %v", e.Path, e.Path, e.Err)
+}
+
+// 파일 내용을 v 로 읽음. 파일이 없으면 v 를 건드리지 않고 (false, nil)
+func (s *jsonStore) Load(v interface{}) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load(v)
+}
+
+func (s *jsonStore) load(v interface{}) (bool, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		cerr := &storeCorruptError{Path: s.path, Err: err}
+		logger("store").Error("JSON 파일 파싱 실패", "path", s.path, "err", err)
+		return false, cerr
+	}
+	return true, nil
+}
+
+// v 전체를 저장
+func (s *jsonStore) Save(v interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.write(v)
+}
+
+// 잠금을 쥔 채로 읽기 → fn(수정) → 쓰기. fn 이 오류를 반환하면 쓰지 않음
+func (s *jsonStore) Update(v interface{}, fn func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.load(v); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	return s.write(v)
+}
+
+func (s *jsonStore) write(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, data, true)
+}
+
+// 같은 디렉토리의 임시 파일에 쓰고 fsync 후 rename. keepBackup 이면 기존 파일을 .bak 으로 보관
+func writeFileAtomic(path string, data []byte, keepBackup bool) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	cleanup := func() { os.Remove(tmpName) }
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		cleanup()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		cleanup()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		cleanup()
+		return err
+	}
+	os.Chmod(tmpName, 0644)
+
+	if keepBackup {
+		if err := backupFile(path, path+".bak"); err != nil && !os.IsNotExist(err) {
+			logger("store").Warn(".bak 백업 실패", "path", path, "err", err)
+		}
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		cleanup()
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// 기존 파일을 백업 위치로 복사 (하드링크 우선, 실패 시 복사)
+func backupFile(src, dst string) error {
+	if _, err := os.Stat(src); err != nil {
+		return err
+	}
+	os.Remove(dst)
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// rename 결과가 디스크에 반영되도록 디렉토리도 fsync
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
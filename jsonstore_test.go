@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestJSONStoreConcurrentUpdates(t *testing.T) {
+	store := &jsonStore{path: filepath.Join(t.TempDir(), "bookmark.json")}
+	const writers = 50
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var list []int
+			if err := store.Update(&list, func() error {
+				list = append(list, i)
+				return nil
+			}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var list []int
+	if ok, err := store.Load(&list); !ok || err != nil {
+		t.Fatalf("Load = %v, %v", ok, err)
+	}
+	if len(list) != writers {
+		t.Errorf("%d entries after %d concurrent updates (lost updates)", len(list), writers)
+	}
+}
+
+func TestJSONStoreKeepsPreviousGeneration(t *testing.T) {
+	store := &jsonStore{path: filepath.Join(t.TempDir(), "state.json")}
+	var v []string
+	if ok, err := store.Load(&v); ok || err != nil {
+		t.Fatalf("missing file: Load = %v, %v; want false, nil", ok, err)
+	}
+	if err := store.Save([]string{"first"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save([]string{"second"}); err != nil {
+		t.Fatal(err)
+	}
+	bak := &jsonStore{path: store.path + ".bak"}
+	if _, err := bak.Load(&v); err != nil || len(v) != 1 || v[0] != "first" {
+		t.Errorf(".bak = %q, %v; want [first]", v, err)
+	}
+	// 임시 파일이 남지 않아야 함
+	if matches, _ := filepath.Glob(filepath.Join(filepath.Dir(store.path), ".state.json.tmp-*")); len(matches) > 0 {
+		t.Errorf("temp files left behind: %q", matches)
+	}
+}
+
+func TestJSONStoreCorruptFileIsNotOverwritten(t *testing.T) {
+	store := &jsonStore{path: filepath.Join(t.TempDir(), "bookmark.json")}
+	corrupt := []byte(`[{"system": "snes", "rom": `)
+	if err := os.WriteFile(store.path, corrupt, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var list []BookmarkItem
+	_, err := store.Load(&list)
+	var cerr *storeCorruptError
+	if !errors.As(err, &cerr) {
+		t.Fatalf("Load error = %v, want storeCorruptError", err)
+	}
+	if err := store.Update(&list, func() error {
+		list = append(list, BookmarkItem{System: "nes", Rom: "a.nes"})
+		return nil
+	}); !errors.As(err, &cerr) {
+		t.Errorf("Update on corrupt file: err = %v, want storeCorruptError", err)
+	}
+	if data, _ := os.ReadFile(store.path); string(data) != string(corrupt) {
+		t.Errorf("corrupt file was overwritten: %q", data)
+	}
+}
+
+func TestJSONStoreUpdateAbortsOnError(t *testing.T) {
+	store := &jsonStore{path: filepath.Join(t.TempDir(), "state.json")}
+	if err := store.Save(map[string]int{"a": 1}); err != nil {
+		t.Fatal(err)
+	}
+	stop := errors.New("stop")
+	v := map[string]int{}
+	if err := store.Update(&v, func() error {
+		v["a"] = 2
+		return stop
+	}); err != stop {
+		t.Fatalf("Update = %v, want %v", err, stop)
+	}
+	v = map[string]int{}
+	store.Load(&v)
+	if v["a"] != 1 {
+		t.Errorf("aborted update was written: %v", v)
+	}
+}
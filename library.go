@@ -0,0 +1,228 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	romsDir         = "./data/roms"
+	savesDir        = "./data/saves"
+	defaultPageSize = 200
+	maxPageSize     = 1000
+)
+
+// [JSON API] 롬 한 개에 대한 정보
+type RomEntry struct {
+	System     string `json:"system"`
+	Name       string `json:"name"`
+	Title      string `json:"title"`
+	Ext        string `json:"ext"`
+	Size       int64  `json:"size"`
+	ModTime    int64  `json:"modTime"`
+	Core       string `json:"core"`
+	Genre      string `json:"genre,omitempty"`
+	Players    int    `json:"players,omitempty"`
+	Year       int    `json:"year,omitempty"`
+	Verified   bool   `json:"verified"`
+	Image      string `json:"image,omitempty"`
+	Bookmarked bool   `json:"bookmarked"`
+	HasSave    bool   `json:"hasSave"`
+	SaveSize   int64  `json:"saveSize,omitempty"`
+	LastPlayed int64  `json:"lastPlayed,omitempty"`
+	PlayCount  int    `json:"playCount,omitempty"`
+	PlayTime   int64  `json:"playTime,omitempty"` // 누적 플레이 시간(초)
+	Discs      int    `json:"discs,omitempty"`    // 여러 장짜리 게임(.m3u)의 디스크 수
+}
+
+type SystemSummary struct {
+	Name  string `json:"name"`
+	Core  string `json:"core"`
+	Count int    `json:"count"`
+	Size  int64  `json:"size"`
+	Known bool   `json:"known"` // [추가] 코어 목록(cores.go)이나 coreMap 에 있는 폴더인지
+}
+
+type RomListResponse struct {
+	Total   int             `json:"total"`
+	Offset  int             `json:"offset"`
+	Limit   int             `json:"limit"`
+	Systems []SystemSummary `json:"systems"`
+	Roms    []RomEntry      `json:"roms"`
+}
+
+// 프론트엔드(Launcher)가 사용하는 세이브 파일 이름: `${sys}-${rom}.sav`
+func saveFileName(sys, rom string) string {
+	return sys + "-" + rom + ".sav"
+}
+
+// 롬 경로 검증용: 시스템/파일명이 실제 롬 폴더에 있는지
+func romExists(sys, rom string) bool {
+	_, ok := resolveRomPath(sys, rom) // [수정] 하위 폴더에 있는 롬 포함
+	return ok
+}
+
+func loadBookmarks() ([]BookmarkItem, error) {
+	var bookmarks []BookmarkItem
+	_, err := bookmarkStore.Load(&bookmarks)
+	return bookmarks, err
+}
+
+// 플레이 통계 필드를 채움. saveTimes 는 항목별 세이브 파일 시각
+// 세션 기록이 있으면 그쪽이 더 정확함 (세이브가 없는 게임도 포함)
+func applyPlayStats(entries []RomEntry, saveTimes []int64, played map[string]PlayStatView) {
+	for i := range entries {
+		e := &entries[i]
+		e.PlayCount, e.PlayTime, e.LastPlayed = 0, 0, saveTimes[i]
+		if st, ok := played[e.System+"/"+e.Name]; ok {
+			e.PlayCount = st.PlayCount
+			e.PlayTime = st.TotalSeconds
+			if st.LastPlayed > e.LastPlayed {
+				e.LastPlayed = st.LastPlayed
+			}
+		}
+	}
+}
+
+// 롬 목록 + 부가 정보(즐겨찾기, 세이브 유무 등)를 시스템/이름 순으로 수집 (플레이 통계는 applyPlayStats)
+func collectRomEntries() ([]RomEntry, []SystemSummary) {
+	config := loadConfigFromHTML()
+	romData := scanRomLibrary(romsDir)
+
+	bookmarked := make(map[string]bool)
+	bookmarks, _ := loadBookmarks() // 파싱 오류는 저장소에서 기록됨
+	for _, b := range bookmarks {
+		bookmarked[b.System+"/"+b.Rom] = true
+	}
+
+	var systems []string
+	for sys := range romData {
+		systems = append(systems, sys)
+	}
+	sort.Strings(systems)
+
+	var entries []RomEntry
+	var summaries []SystemSummary
+	for _, sys := range systems {
+		roms := romData[sys]
+		sort.Slice(roms, func(i, j int) bool { return roms[i].Name < roms[j].Name })
+
+		summary := SystemSummary{Name: sys, Core: coreForSystem(config, sys), Count: len(roms)}
+		summary.Known = summary.Core != ""
+		if !summary.Known {
+			logger("library").Warn("코어를 알 수 없는 롬 폴더 (실행되지 않음)", "folder", sys, "roms", len(roms))
+		}
+		for _, rom := range roms {
+			ext := filepath.Ext(rom.Name)
+			entry := RomEntry{
+				System:     sys,
+				Name:       rom.Name,
+				Title:      strings.TrimSuffix(rom.Name, ext),
+				Ext:        strings.ToLower(ext),
+				Size:       rom.Size,
+				ModTime:    rom.ModTime,
+				Core:       summary.Core,
+				Image:      cardImageURL(sys, rom.Name),
+				Bookmarked: bookmarked[sys+"/"+rom.Name],
+				Discs:      rom.Discs,
+			}
+			if info, err := os.Stat(filepath.Join(savesDir, saveFileName(sys, rom.Name))); err == nil {
+				entry.HasSave = true
+				entry.SaveSize = info.Size()
+				entry.LastPlayed = info.ModTime().Unix()
+			}
+			summary.Size += rom.Size
+			entries = append(entries, entry)
+		}
+		summaries = append(summaries, summary)
+	}
+	return entries, summaries
+}
+
+// [JSON API] GET /api/roms?offset=&limit= (+ 검색/필터/정렬 조건은 parseRomQuery 참고)
+func handleRomsAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	offset, limit, err := parsePage(r)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	query, err := parseRomQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	idx := getLibraryIndex()
+	entries := idx.search(query)
+	resp := RomListResponse{Total: len(entries), Offset: offset, Limit: limit, Systems: idx.summaries}
+	if resp.Systems == nil {
+		resp.Systems = []SystemSummary{}
+	}
+	if offset < len(entries) {
+		end := offset + limit
+		if end > len(entries) {
+			end = len(entries)
+		}
+		resp.Roms = entries[offset:end]
+	}
+	if resp.Roms == nil {
+		resp.Roms = []RomEntry{}
+	}
+
+	writeJSONWithETag(w, r, resp)
+}
+
+func parsePage(r *http.Request) (int, int, error) {
+	offset, limit := 0, defaultPageSize
+	if v := r.URL.Query().Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return 0, 0, fmt.Errorf("Invalid offset")
+		}
+		offset = n
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return 0, 0, fmt.Errorf("Invalid limit")
+		}
+		limit = n
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+	return offset, limit, nil
+}
+
+// JSON 응답 + 내용 기반 ETag (If-None-Match 일치 시 304)
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, "Encode failed", 500)
+		return
+	}
+	hash := md5.Sum(body)
+	etag := hex.EncodeToString(hash[:])
+
+	// 304 에도 200 과 같은 ETag / Cache-Control 을 보냄 (RFC 9110)
+	w.Header().Set("ETag", fmt.Sprintf(`"%s"`, etag))
+	w.Header().Set("Cache-Control", "no-cache")
+	if match := r.Header.Get("If-None-Match"); match != "" && strings.Contains(match, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
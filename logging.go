@@ -0,0 +1,117 @@
+package main
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// [로그] log/slog 기반 구조화 로그
+//
+//	RETRO_LOG_FORMAT=json  → JSON 한 줄 로그 (기본: text)
+//	RETRO_LOG_LEVEL=debug|info|warn|error (기본: info)
+const (
+	logFormatEnv = "RETRO_LOG_FORMAT"
+	logLevelEnv  = "RETRO_LOG_LEVEL"
+)
+
+func setupLogging() {
+	level := slog.LevelInfo
+	switch strings.ToLower(os.Getenv(logLevelEnv)) {
+	case "debug":
+		level = slog.LevelDebug
+	case "warn":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	if strings.ToLower(os.Getenv(logFormatEnv)) == "json" {
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	} else {
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	}
+	// 기존 log 패키지 출력도 같은 핸들러로 모임
+	slog.SetDefault(slog.New(handler))
+}
+
+// 기능(컴포넌트)별 로거. slog.SetDefault 이후의 핸들러를 쓰도록 호출할 때마다 만든다
+func logger(component string) *slog.Logger {
+	return slog.Default().With("component", component)
+}
+
+// 응답 코드와 크기를 기록하기 위한 ResponseWriter 래퍼
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.status == 0 {
+		s.status = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	n, err := s.ResponseWriter.Write(b)
+	s.bytes += int64(n)
+	return n, err
+}
+
+// http.ResponseController (Flush 등) 가 원래 ResponseWriter 에 닿도록
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// 요청한 클라이언트 주소 (caddy 등 리버스 프록시 뒤에서는 X-Forwarded-For 의 첫 주소)
+func clientAddr(r *http.Request) string {
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		return strings.TrimSpace(strings.Split(fwd, ",")[0])
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// 접근 로그. 5xx 는 error, 4xx 는 warn, API 와 메인 페이지는 info,
+// 정적 파일(에뮬레이터 코드, 롬, 이미지 등)은 양이 많아 debug 로 남긴다
+func accessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		level := slog.LevelDebug
+		switch {
+		case rec.status >= 500:
+			level = slog.LevelError
+		case rec.status >= 400:
+			level = slog.LevelWarn
+		case strings.HasPrefix(r.URL.Path, "/api/") || r.URL.Path == "/" || r.URL.Path == "/index.html":
+			level = slog.LevelInfo
+		}
+		slog.Default().LogAttrs(r.Context(), level, "request",
+			slog.String("component", "http"),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Int64("bytes", rec.bytes),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("client", clientAddr(r)),
+		)
+	})
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// [메트릭] Prometheus 텍스트 포맷(0.0.4)으로 직접 출력하는 /metrics
+// 외부 라이브러리 없이 필요한 카운터/히스토그램만 둔다
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 120}
+
+type histogram struct {
+	counts []uint64 // latencyBuckets 별 (누적 아님)
+	sum    float64
+	count  uint64
+}
+
+func (h *histogram) observe(v float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(latencyBuckets))
+	}
+	for i, b := range latencyBuckets {
+		if v <= b {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+type requestKey struct {
+	Route, Method, Status string
+}
+
+type jobKey struct {
+	Job, Result string
+}
+
+var metrics struct {
+	sync.Mutex
+	requests     map[requestKey]uint64
+	latency      map[string]*histogram // route 별
+	servedBytes  map[string]uint64     // roms / cores
+	indexCache   map[string]uint64     // hit / miss / rebuild
+	indexRebuild histogram
+	jobs         map[jobKey]uint64
+}
+
+var serverStartTime = time.Now()
+
+// 라벨 수가 늘어나지 않도록 경로를 등록된 패턴 단위로 묶음
+// ("/" 아래 정적 파일은 롬/코어/에뮬레이터/기타로 구분)
+func metricsRoute(r *http.Request) string {
+	if _, pattern := http.DefaultServeMux.Handler(r); pattern != "/" && pattern != "" {
+		return pattern
+	}
+	p := r.URL.Path
+	switch {
+	case p == "/" || p == "/index.html":
+		return "/"
+	case strings.HasPrefix(p, "/data/roms/"):
+		return "/data/roms/"
+	case strings.HasPrefix(p, "/emulatorjs/data/cores/"):
+		return "/emulatorjs/data/cores/"
+	case strings.HasPrefix(p, "/emulatorjs/"):
+		return "/emulatorjs/"
+	case strings.HasPrefix(p, "/data/"):
+		return "/data/"
+	}
+	return "static"
+}
+
+func instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		route := metricsRoute(r)
+		elapsed := time.Since(start).Seconds()
+
+		metrics.Lock()
+		defer metrics.Unlock()
+		if metrics.requests == nil {
+			metrics.requests = make(map[requestKey]uint64)
+			metrics.latency = make(map[string]*histogram)
+			metrics.servedBytes = make(map[string]uint64)
+		}
+		metrics.requests[requestKey{route, r.Method, fmt.Sprint(rec.status)}]++
+		h := metrics.latency[route]
+		if h == nil {
+			h = &histogram{}
+			metrics.latency[route] = h
+		}
+		h.observe(elapsed)
+		switch route {
+		case "/data/roms/":
+			metrics.servedBytes["roms"] += uint64(rec.bytes)
+		case "/emulatorjs/data/cores/":
+			metrics.servedBytes["cores"] += uint64(rec.bytes)
+		}
+	})
+}
+
+// SSR 캐시 결과 기록 (hit / miss)
+func observeIndexCache(result string) {
+	metrics.Lock()
+	defer metrics.Unlock()
+	if metrics.indexCache == nil {
+		metrics.indexCache = make(map[string]uint64)
+	}
+	metrics.indexCache[result]++
+}
+
+func observeIndexRebuild(d time.Duration) {
+	observeIndexCache("rebuild")
+	metrics.Lock()
+	defer metrics.Unlock()
+	metrics.indexRebuild.observe(d.Seconds())
+}
+
+// 코어 동기화/인젝트 작업 결과 기록
+func observeJob(job, result string) {
+	metrics.Lock()
+	defer metrics.Unlock()
+	if metrics.jobs == nil {
+		metrics.jobs = make(map[jobKey]uint64)
+	}
+	metrics.jobs[jobKey{job, result}]++
+}
+
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+// 라벨 문자열("a=\"x\",b=\"y\"")을 정렬된 순서로 출력하기 위한 도우미
+type metricLine struct {
+	labels string
+	value  float64
+}
+
+func writeMetric(sb *strings.Builder, name, kind, help string, lines []metricLine) {
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	sort.Slice(lines, func(i, j int) bool { return lines[i].labels < lines[j].labels })
+	for _, l := range lines {
+		if l.labels == "" {
+			fmt.Fprintf(sb, "%s %v\n", name, l.value)
+		} else {
+			fmt.Fprintf(sb, "%s{%s} %v\n", name, l.labels, l.value)
+		}
+	}
+}
+
+func writeHistogram(sb *strings.Builder, name, labels string, h *histogram) {
+	prefix := ""
+	if labels != "" {
+		prefix = labels + ","
+	}
+	var cumulative uint64
+	for i, b := range latencyBuckets {
+		if h.counts != nil {
+			cumulative += h.counts[i]
+		}
+		fmt.Fprintf(sb, "%s_bucket{%sle=\"%v\"} %d\n", name, prefix, b, cumulative)
+	}
+	fmt.Fprintf(sb, "%s_bucket{%sle=\"+Inf\"} %d\n", name, prefix, h.count)
+	if labels == "" {
+		fmt.Fprintf(sb, "%s_sum %v\n%s_count %d\n", name, h.sum, name, h.count)
+	} else {
+		fmt.Fprintf(sb, "%s_sum{%s} %v\n%s_count{%s} %d\n", name, labels, h.sum, name, labels, h.count)
+	}
+}
+
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	var sb strings.Builder
+
+	metrics.Lock()
+	var lines []metricLine
+	for k, v := range metrics.requests {
+		lines = append(lines, metricLine{fmt.Sprintf(`route="%s",method="%s",status="%s"`, escapeLabel(k.Route), escapeLabel(k.Method), k.Status), float64(v)})
+	}
+	writeMetric(&sb, "retro_http_requests_total", "counter", "HTTP requests by route, method and status.", lines)
+
+	sb.WriteString("# HELP retro_http_request_duration_seconds HTTP request latency by route.\n# TYPE retro_http_request_duration_seconds histogram\n")
+	routes := make([]string, 0, len(metrics.latency))
+	for route := range metrics.latency {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	for _, route := range routes {
+		writeHistogram(&sb, "retro_http_request_duration_seconds", fmt.Sprintf(`route="%s"`, escapeLabel(route)), metrics.latency[route])
+	}
+
+	lines = nil
+	for _, kind := range []string{"roms", "cores"} {
+		lines = append(lines, metricLine{fmt.Sprintf(`kind="%s"`, kind), float64(metrics.servedBytes[kind])})
+	}
+	writeMetric(&sb, "retro_served_bytes_total", "counter", "Response bytes served for ROM and core files.", lines)
+
+	lines = nil
+	for _, result := range []string{"hit", "miss", "rebuild"} {
+		lines = append(lines, metricLine{fmt.Sprintf(`result="%s"`, result), float64(metrics.indexCache[result])})
+	}
+	writeMetric(&sb, "retro_index_cache_total", "counter", "SSR index cache lookups and rebuilds.", lines)
+
+	sb.WriteString("# HELP retro_index_rebuild_duration_seconds Time spent rendering the SSR index page.\n# TYPE retro_index_rebuild_duration_seconds histogram\n")
+	writeHistogram(&sb, "retro_index_rebuild_duration_seconds", "", &metrics.indexRebuild)
+
+	lines = nil
+	for k, v := range metrics.jobs {
+		lines = append(lines, metricLine{fmt.Sprintf(`job="%s",result="%s"`, k.Job, k.Result), float64(v)})
+	}
+	writeMetric(&sb, "retro_jobs_total", "counter", "Core sync and ROM inject job outcomes.", lines)
+	metrics.Unlock()
+
+	// 진행 중인 작업 (processingFiles 키: core_download, inject:<sys>:<rom>)
+	active := map[string]int{"core_download": 0, "inject": 0}
+	processingMutex.Lock()
+	for key := range processingFiles {
+		kind, _, _ := strings.Cut(key, ":")
+		active[kind]++
+	}
+	processingMutex.Unlock()
+	lines = nil
+	for kind, n := range active {
+		lines = append(lines, metricLine{fmt.Sprintf(`job="%s"`, escapeLabel(kind)), float64(n)})
+	}
+	writeMetric(&sb, "retro_active_jobs", "gauge", "Jobs currently running.", lines)
+
+	playSessions.Lock()
+	sessions := len(playSessions.active)
+	users := make(map[string]bool)
+	for _, sess := range playSessions.active {
+		users[sess.User] = true
+	}
+	playSessions.Unlock()
+	writeMetric(&sb, "retro_active_sessions", "gauge", "Open play sessions.", []metricLine{{"", float64(sessions)}})
+	writeMetric(&sb, "retro_active_players", "gauge", "Distinct users with an open play session.", []metricLine{{"", float64(len(users))}})
+
+	wd, _ := os.Getwd()
+	free, total := getDiskUsage(wd)
+	writeMetric(&sb, "retro_disk_free_bytes", "gauge", "Free disk space available to the server.", []metricLine{{"", float64(free)}})
+	writeMetric(&sb, "retro_disk_total_bytes", "gauge", "Total disk space of the data volume.", []metricLine{{"", float64(total)}})
+
+	writeMetric(&sb, "retro_start_time_seconds", "gauge", "Server start time in unix seconds.", []metricLine{{"", float64(serverStartTime.Unix())}})
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	writeMetric(&sb, "go_goroutines", "gauge", "Number of goroutines.", []metricLine{{"", float64(runtime.NumGoroutine())}})
+	writeMetric(&sb, "go_memstats_alloc_bytes", "gauge", "Bytes of allocated heap objects.", []metricLine{{"", float64(mem.Alloc)}})
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(sb.String()))
+}
@@ -0,0 +1,565 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// [넷플레이] EmulatorJS 넷플레이용 방 목록/시그널링 서버 (외부 서비스 없이 LAN 안에서 2인 협력 플레이)
+// EmulatorJS 의 socket.io 클라이언트(data/src/socket.io.min.js)와 Engine.IO v4 long-polling 으로 통신한다.
+// 게임 화면과 입력은 기기끼리 WebRTC 로 직접 주고받고, 서버는 방 관리와 offer/answer/ICE 중계만 한다.
+//
+//	GET  /netplay/list?domain=&game_id=   열린 방 목록
+//	/socket.io/?EIO=4&transport=polling   네임스페이스 /netplay (EJS_netplayUrl = <주소>/netplay)
+const (
+	eioPingInterval = 25 * time.Second
+	eioPingTimeout  = 20 * time.Second
+	eioMaxPayload   = 32 << 20 // 상태 저장 파일이 data-message 로 오갈 수 있음
+	eioSeparator    = "\x1e"
+	netplayMaxRooms = 64
+)
+
+type eioSession struct {
+	sid      string
+	sockID   string // socket.io 소켓 ID (players 의 socketId, webrtc-signal 의 target/sender)
+	nsp      string
+	joined   bool // 네임스페이스에 연결됨
+	out      []string
+	wake     chan struct{}
+	polling  bool
+	lastSeen time.Time
+	closed   bool
+
+	room   string // 들어가 있는 방 (sessionid)
+	player string // userid
+
+	// 바이너리 첨부가 있는 이벤트를 받는 중 (첨부 개수만큼 "b..." 패킷이 뒤따름)
+	pending *sioPacket
+}
+
+type NetplayRoom struct {
+	ID       string
+	Name     string
+	GameID   string
+	Domain   string
+	Owner    string
+	Max      int
+	Password string
+	Players  map[string]map[string]interface{} // userid → extra (+ socketId)
+	order    []string                          // 들어온 순서
+	sessions map[string]*eioSession
+	Created  time.Time
+}
+
+var netplay struct {
+	sync.Mutex
+	sessions map[string]*eioSession
+	rooms    map[string]*NetplayRoom
+}
+
+var netplayReaperOnce sync.Once
+
+// socket.io 패킷 (Socket.IO 프로토콜 v5)
+type sioPacket struct {
+	Type        byte // '0' connect, '1' disconnect, '2' event, '3' ack, '4' error, '5' binary event, '6' binary ack
+	Attachments int
+	Nsp         string
+	AckID       int // -1 이면 없음
+	Data        string
+	atts        []string // 받은 첨부 ("b<base64>" 엔진 패킷 그대로)
+}
+
+func parseSioPacket(s string) (*sioPacket, error) {
+	if s == "" {
+		return nil, fmt.Errorf("empty packet")
+	}
+	p := &sioPacket{Type: s[0], Nsp: "/", AckID: -1}
+	rest := s[1:]
+	if p.Type == '5' || p.Type == '6' {
+		i := strings.IndexByte(rest, '-')
+		if i < 0 {
+			return nil, fmt.Errorf("bad binary packet")
+		}
+		n, err := strconv.Atoi(rest[:i])
+		if err != nil {
+			return nil, err
+		}
+		p.Attachments, rest = n, rest[i+1:]
+	}
+	if strings.HasPrefix(rest, "/") {
+		i := strings.IndexByte(rest, ',')
+		if i < 0 {
+			p.Nsp, rest = rest, ""
+		} else {
+			p.Nsp, rest = rest[:i], rest[i+1:]
+		}
+	}
+	i := 0
+	for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+		i++
+	}
+	if i > 0 {
+		p.AckID, _ = strconv.Atoi(rest[:i])
+	}
+	p.Data = rest[i:]
+	return p, nil
+}
+
+func (p *sioPacket) encode() string {
+	var sb strings.Builder
+	sb.WriteByte(p.Type)
+	if p.Type == '5' || p.Type == '6' {
+		sb.WriteString(strconv.Itoa(p.Attachments) + "-")
+	}
+	if p.Nsp != "" && p.Nsp != "/" {
+		sb.WriteString(p.Nsp + ",")
+	}
+	if p.AckID >= 0 {
+		sb.WriteString(strconv.Itoa(p.AckID))
+	}
+	sb.WriteString(p.Data)
+	return sb.String()
+}
+
+// 잠금 상태에서 호출. 엔진 패킷을 보낼 목록에 넣고 대기 중인 polling 요청을 깨움
+func (s *eioSession) send(packets ...string) {
+	if s.closed {
+		return
+	}
+	s.out = append(s.out, packets...)
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// 잠금 상태에서 호출. socket.io 이벤트 전송 (args 는 JSON 값)
+func (s *eioSession) emit(event string, args ...interface{}) {
+	payload := append([]interface{}{event}, args...)
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	s.send("4" + (&sioPacket{Type: '2', Nsp: s.nsp, AckID: -1, Data: string(data)}).encode())
+}
+
+func (s *eioSession) ack(id int, args ...interface{}) {
+	if id < 0 {
+		return
+	}
+	if args == nil {
+		args = []interface{}{}
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return
+	}
+	s.send("4" + (&sioPacket{Type: '3', Nsp: s.nsp, AckID: id, Data: string(data)}).encode())
+}
+
+// 잠금 상태에서 호출. 받은 이벤트를 첨부까지 그대로 다른 소켓에 전달
+func (s *eioSession) relay(p *sioPacket) {
+	out := &sioPacket{Type: p.Type, Attachments: p.Attachments, Nsp: s.nsp, AckID: -1, Data: p.Data}
+	s.send(append([]string{"4" + out.encode()}, p.atts...)...)
+}
+
+func newEioSession() *eioSession {
+	netplayReaperOnce.Do(func() { go netplayReaper() })
+	s := &eioSession{sid: newID(), wake: make(chan struct{}, 1), lastSeen: time.Now()}
+	netplay.Lock()
+	if netplay.sessions == nil {
+		netplay.sessions = make(map[string]*eioSession)
+		netplay.rooms = make(map[string]*NetplayRoom)
+	}
+	netplay.sessions[s.sid] = s
+	netplay.Unlock()
+	return s
+}
+
+// 잠금 상태에서 호출
+func closeEioSession(s *eioSession, reason string) {
+	if s.closed {
+		return
+	}
+	leaveNetplayRoom(s)
+	s.send("1")
+	s.closed = true
+	delete(netplay.sessions, s.sid)
+	logger("netplay").Debug("연결 종료", "sid", s.sid, "reason", reason)
+}
+
+// 응답이 없는 연결 정리 (브라우저 탭을 닫았거나 기기가 잠들었을 때)
+func netplayReaper() {
+	for range time.Tick(10 * time.Second) {
+		netplay.Lock()
+		for _, s := range netplay.sessions {
+			if !s.polling && time.Since(s.lastSeen) > eioPingInterval+eioPingTimeout {
+				closeEioSession(s, "ping timeout")
+			}
+		}
+		netplay.Unlock()
+	}
+}
+
+// 잠금 상태에서 호출
+func broadcastNetplayUsers(room *NetplayRoom) {
+	for _, s := range room.sessions {
+		s.emit("users-updated", room.Players)
+	}
+}
+
+// 잠금 상태에서 호출. 방의 플레이어 목록에서 userid 를 뺌 (순서 목록에 중복이 남지 않도록 모두 제거)
+func removeNetplayPlayer(room *NetplayRoom, userid string) {
+	delete(room.Players, userid)
+	delete(room.sessions, userid)
+	order := room.order[:0]
+	for _, id := range room.order {
+		if id != userid {
+			order = append(order, id)
+		}
+	}
+	room.order = order
+}
+
+// 잠금 상태에서 호출. 방장이 나가면 다음 사람이 방장, 아무도 없으면 방 삭제
+func leaveNetplayRoom(s *eioSession) {
+	room := netplay.rooms[s.room]
+	s.room = ""
+	if room == nil || room.sessions[s.player] != s {
+		return // 같은 userid 로 다시 들어온 연결이 이미 자리를 넘겨받음
+	}
+	removeNetplayPlayer(room, s.player)
+	if len(room.order) == 0 {
+		delete(netplay.rooms, room.ID)
+		logger("netplay").Info("방 닫힘", "room", room.Name, "id", room.ID)
+		return
+	}
+	if room.Owner == s.player {
+		room.Owner = room.order[0]
+	}
+	broadcastNetplayUsers(room)
+}
+
+type netplayRoomRequest struct {
+	Extra      map[string]interface{} `json:"extra"`
+	MaxPlayers int                    `json:"maxPlayers"`
+	Password   string                 `json:"password"`
+}
+
+func extraString(extra map[string]interface{}, key string) string {
+	switch v := extra[key].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// 잠금 상태에서 호출
+func handleNetplayEvent(s *eioSession, p *sioPacket) {
+	var args []json.RawMessage
+	if err := json.Unmarshal([]byte(p.Data), &args); err != nil || len(args) == 0 {
+		return
+	}
+	var event string
+	json.Unmarshal(args[0], &event)
+	var req netplayRoomRequest
+	if len(args) > 1 {
+		json.Unmarshal(args[1], &req)
+	}
+
+	switch event {
+	case "open-room", "join-room":
+		userid, sessionid := extraString(req.Extra, "userid"), extraString(req.Extra, "sessionid")
+		if userid == "" || sessionid == "" {
+			s.ack(p.AckID, "Invalid room request")
+			return
+		}
+		leaveNetplayRoom(s)
+		room := netplay.rooms[sessionid]
+		if event == "open-room" {
+			if room != nil {
+				s.ack(p.AckID, "Room already exists")
+				return
+			}
+			if len(netplay.rooms) >= netplayMaxRooms {
+				s.ack(p.AckID, "Too many rooms")
+				return
+			}
+			room = &NetplayRoom{
+				ID:       sessionid,
+				Name:     extraString(req.Extra, "room_name"),
+				GameID:   extraString(req.Extra, "game_id"),
+				Domain:   extraString(req.Extra, "domain"),
+				Owner:    userid,
+				Max:      req.MaxPlayers,
+				Password: req.Password,
+				Players:  make(map[string]map[string]interface{}),
+				sessions: make(map[string]*eioSession),
+				Created:  time.Now(),
+			}
+			if room.Max <= 0 {
+				room.Max = 4
+			}
+			netplay.rooms[sessionid] = room
+			logger("netplay").Info("방 생성", "room", room.Name, "id", room.ID, "game", room.GameID, "max", room.Max)
+		} else {
+			// 다시 연결한 경우: 같은 userid 의 이전 연결을 방에서 빼고 자리를 이어받음
+			rejoin := room != nil && room.Players[userid] != nil
+			switch {
+			case room == nil:
+				s.ack(p.AckID, "Room not found")
+				return
+			case room.Password != "" && room.Password != req.Password:
+				s.ack(p.AckID, "Incorrect password")
+				return
+			case rejoin:
+				if old := room.sessions[userid]; old != nil && old != s {
+					old.room = ""
+				}
+				removeNetplayPlayer(room, userid)
+			case len(room.order) >= room.Max:
+				s.ack(p.AckID, "Room is full")
+				return
+			}
+		}
+		req.Extra["socketId"] = s.sockID
+		room.Players[userid] = req.Extra
+		room.sessions[userid] = s
+		room.order = append(room.order, userid)
+		s.room, s.player = room.ID, userid
+		s.ack(p.AckID, nil, room.Players)
+		broadcastNetplayUsers(room)
+
+	case "leave-room":
+		leaveNetplayRoom(s)
+		s.ack(p.AckID)
+
+	case "webrtc-signal":
+		// target(상대 socketId)에게만 전달하고 보낸 쪽을 sender 로 붙임
+		var signal map[string]interface{}
+		if len(args) < 2 || json.Unmarshal(args[1], &signal) != nil {
+			return
+		}
+		target, _ := signal["target"].(string)
+		delete(signal, "target")
+		signal["sender"] = s.sockID
+		if room := netplay.rooms[s.room]; room != nil {
+			for _, peer := range room.sessions {
+				if peer.sockID == target {
+					peer.emit("webrtc-signal", signal)
+				}
+			}
+		}
+
+	case "webrtc-signal-error":
+		logger("netplay").Warn("WebRTC 시그널 오류 (클라이언트)", "room", s.room, "data", string(args[len(args)-1]))
+
+	default:
+		// data-message 등 나머지 이벤트는 같은 방의 다른 사람에게 그대로 전달
+		if room := netplay.rooms[s.room]; room != nil {
+			for _, peer := range room.sessions {
+				if peer != s {
+					peer.relay(p)
+				}
+			}
+		}
+	}
+}
+
+// 잠금 상태에서 호출. 엔진 패킷 하나 처리
+func handleEioPacket(s *eioSession, packet string) {
+	if packet == "" {
+		return
+	}
+	if s.pending != nil {
+		if packet[0] == 'b' {
+			s.pending.atts = append(s.pending.atts, packet)
+			if len(s.pending.atts) == s.pending.Attachments {
+				p := s.pending
+				s.pending = nil
+				handleNetplayEvent(s, p)
+			}
+			return
+		}
+		s.pending = nil // 첨부가 오지 않음
+	}
+
+	switch packet[0] {
+	case '1': // close
+		closeEioSession(s, "client close")
+	case '2': // ping (클라이언트가 보내는 경우는 없지만 응답)
+		s.send("3" + packet[1:])
+	case '3', '6': // pong, noop
+	case '4':
+		p, err := parseSioPacket(packet[1:])
+		if err != nil {
+			return
+		}
+		switch p.Type {
+		case '0':
+			s.nsp, s.joined = p.Nsp, true
+			if s.sockID == "" {
+				s.sockID = newID()
+			}
+			data, _ := json.Marshal(map[string]string{"sid": s.sockID})
+			s.send("4" + (&sioPacket{Type: '0', Nsp: s.nsp, AckID: -1, Data: string(data)}).encode())
+		case '1':
+			leaveNetplayRoom(s)
+			s.joined = false
+		case '2':
+			if s.joined {
+				handleNetplayEvent(s, p)
+			}
+		case '5':
+			if s.joined {
+				s.pending = p
+			}
+		}
+	}
+}
+
+func writeEioError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+	json.NewEncoder(w).Encode(map[string]interface{}{"code": code, "message": message})
+}
+
+// /socket.io/ : Engine.IO v4 polling (websocket 업그레이드는 지원하지 않음)
+func handleSocketIO(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("EIO") != "4" {
+		writeEioError(w, 5, "Unsupported protocol version")
+		return
+	}
+	if q.Get("transport") != "polling" {
+		writeEioError(w, 0, "Transport unknown")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+
+	sid := q.Get("sid")
+	if sid == "" {
+		if r.Method != "GET" {
+			writeEioError(w, 2, "Bad handshake method")
+			return
+		}
+		s := newEioSession()
+		data, _ := json.Marshal(map[string]interface{}{
+			"sid":          s.sid,
+			"upgrades":     []string{},
+			"pingInterval": eioPingInterval.Milliseconds(),
+			"pingTimeout":  eioPingTimeout.Milliseconds(),
+			"maxPayload":   eioMaxPayload,
+		})
+		w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+		io.WriteString(w, "0"+string(data))
+		return
+	}
+
+	netplay.Lock()
+	s := netplay.sessions[sid]
+	if s != nil {
+		s.lastSeen = time.Now()
+	}
+	netplay.Unlock()
+	if s == nil {
+		writeEioError(w, 1, "Session ID unknown")
+		return
+	}
+
+	switch r.Method {
+	case "POST":
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, eioMaxPayload))
+		if err != nil {
+			writeEioError(w, 3, "Bad request")
+			return
+		}
+		netplay.Lock()
+		for _, packet := range strings.Split(string(body), eioSeparator) {
+			handleEioPacket(s, packet)
+		}
+		netplay.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, "ok")
+
+	case "GET":
+		netplay.Lock()
+		if s.polling {
+			netplay.Unlock()
+			writeEioError(w, 3, "Overlapping polling request")
+			return
+		}
+		s.polling = true
+		netplay.Unlock()
+
+		select {
+		case <-s.wake:
+		case <-time.After(eioPingInterval):
+		case <-r.Context().Done():
+		}
+
+		netplay.Lock()
+		s.polling = false
+		s.lastSeen = time.Now()
+		out := s.out
+		s.out = nil
+		if len(out) == 0 && !s.closed {
+			out = []string{"2"} // ping (클라이언트가 pong 으로 응답)
+		}
+		netplay.Unlock()
+		w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+		io.WriteString(w, strings.Join(out, eioSeparator))
+
+	default:
+		http.Error(w, "Method not allowed", 405)
+	}
+}
+
+type NetplayRoomInfo struct {
+	RoomName    string `json:"room_name"`
+	Current     int    `json:"current"`
+	Max         int    `json:"max"`
+	HasPassword bool   `json:"hasPassword"`
+}
+
+// GET /netplay/list?domain=&game_id= : EmulatorJS 방 목록 ({sessionid: {room_name, current, max, hasPassword}})
+func handleNetplayList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	domain, gameID := r.URL.Query().Get("domain"), r.URL.Query().Get("game_id")
+	list := make(map[string]NetplayRoomInfo)
+	netplay.Lock()
+	for id, room := range netplay.rooms {
+		if (domain != "" && room.Domain != domain) || (gameID != "" && room.GameID != gameID) {
+			continue
+		}
+		list[id] = NetplayRoomInfo{RoomName: room.Name, Current: len(room.order), Max: room.Max, HasPassword: room.Password != ""}
+	}
+	netplay.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(list)
+}
+
+// 관리 현황용 (방 이름 순)
+func netplayRooms() []NetplayRoomInfo {
+	netplay.Lock()
+	defer netplay.Unlock()
+	rooms := []NetplayRoomInfo{}
+	for _, room := range netplay.rooms {
+		rooms = append(rooms, NetplayRoomInfo{RoomName: room.Name, Current: len(room.order), Max: room.Max, HasPassword: room.Password != ""})
+	}
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].RoomName < rooms[j].RoomName })
+	return rooms
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func resetNetplay() {
+	netplay.Lock()
+	netplay.sessions = make(map[string]*eioSession)
+	netplay.rooms = make(map[string]*NetplayRoom)
+	netplay.Unlock()
+}
+
+// /netplay 네임스페이스에 연결된 세션
+func netplayConnect(t *testing.T) *eioSession {
+	t.Helper()
+	s := newEioSession()
+	netplay.Lock()
+	defer netplay.Unlock()
+	handleEioPacket(s, "40/netplay,")
+	s.out = nil
+	return s
+}
+
+// 이벤트를 보내고 ack 인자를 돌려줌
+func netplayEmit(t *testing.T, s *eioSession, ackID int, event string, arg interface{}) []json.RawMessage {
+	t.Helper()
+	data, _ := json.Marshal([]interface{}{event, arg})
+	netplay.Lock()
+	defer netplay.Unlock()
+	s.out = nil
+	handleEioPacket(s, fmt.Sprintf("42/netplay,%d%s", ackID, data))
+	prefix := fmt.Sprintf("43/netplay,%d", ackID)
+	for _, packet := range s.out {
+		if rest, ok := strings.CutPrefix(packet, prefix); ok {
+			var args []json.RawMessage
+			if err := json.Unmarshal([]byte(rest), &args); err != nil {
+				t.Fatalf("bad ack %q", packet)
+			}
+			return args
+		}
+	}
+	t.Fatalf("no ack for %s (out %q)", event, s.out)
+	return nil
+}
+
+func roomRequest(userid, password string, max int) netplayRoomRequest {
+	return netplayRoomRequest{
+		Extra:      map[string]interface{}{"userid": userid, "sessionid": "room1", "room_name": "Co-op", "game_id": "snes-Game", "domain": "lan"},
+		MaxPlayers: max,
+		Password:   password,
+	}
+}
+
+// ack 의 첫 인자가 null 이면 성공, 아니면 오류 문자열
+func ackError(args []json.RawMessage) string {
+	var msg string
+	if len(args) > 0 && string(args[0]) != "null" {
+		json.Unmarshal(args[0], &msg)
+	}
+	return msg
+}
+
+func netplayRoom(t *testing.T) *NetplayRoom {
+	t.Helper()
+	netplay.Lock()
+	defer netplay.Unlock()
+	return netplay.rooms["room1"]
+}
+
+func TestNetplayJoinLimits(t *testing.T) {
+	resetNetplay()
+	host, guest, third := netplayConnect(t), netplayConnect(t), netplayConnect(t)
+
+	if msg := ackError(netplayEmit(t, host, 1, "open-room", roomRequest("host", "pw", 2))); msg != "" {
+		t.Fatalf("open-room: %s", msg)
+	}
+	if msg := ackError(netplayEmit(t, third, 2, "open-room", roomRequest("third", "", 2))); msg != "Room already exists" {
+		t.Errorf("second open-room = %q", msg)
+	}
+	if msg := ackError(netplayEmit(t, guest, 3, "join-room", roomRequest("guest", "wrong", 0))); msg != "Incorrect password" {
+		t.Errorf("join with wrong password = %q", msg)
+	}
+	args := netplayEmit(t, guest, 4, "join-room", roomRequest("guest", "pw", 0))
+	if msg := ackError(args); msg != "" {
+		t.Fatalf("join-room: %s", msg)
+	}
+	var players map[string]map[string]interface{}
+	if len(args) < 2 || json.Unmarshal(args[1], &players) != nil || len(players) != 2 {
+		t.Errorf("join ack players = %s", args)
+	}
+	if msg := ackError(netplayEmit(t, third, 5, "join-room", roomRequest("third", "pw", 0))); msg != "Room is full" {
+		t.Errorf("join full room = %q", msg)
+	}
+	if rooms := netplayRooms(); len(rooms) != 1 || rooms[0].Current != 2 || rooms[0].Max != 2 || !rooms[0].HasPassword {
+		t.Errorf("rooms = %+v", rooms)
+	}
+}
+
+func TestNetplayRejoinTakesOverSeat(t *testing.T) {
+	resetNetplay()
+	host, guest := netplayConnect(t), netplayConnect(t)
+	netplayEmit(t, host, 1, "open-room", roomRequest("host", "", 2))
+	netplayEmit(t, guest, 2, "join-room", roomRequest("guest", "", 0))
+
+	// 기기가 잠깐 끊겼다가 새 연결로 같은 userid 로 다시 들어옴 (방이 가득 차 있어도 자리를 이어받음)
+	again := netplayConnect(t)
+	if msg := ackError(netplayEmit(t, again, 3, "join-room", roomRequest("guest", "", 0))); msg != "" {
+		t.Fatalf("rejoin: %s", msg)
+	}
+	room := netplayRoom(t)
+	if len(room.order) != 2 || room.sessions["guest"] != again || room.Players["guest"]["socketId"] != again.sockID {
+		t.Fatalf("after rejoin order=%q socket=%v", room.order, room.Players["guest"]["socketId"])
+	}
+
+	// 이전 연결이 늦게 닫혀도 새 연결의 자리는 그대로
+	netplay.Lock()
+	closeEioSession(guest, "ping timeout")
+	netplay.Unlock()
+	if room := netplayRoom(t); room.sessions["guest"] != again || len(room.order) != 2 {
+		t.Errorf("closing the stale connection removed the rejoined player: order=%q", room.order)
+	}
+}
+
+func TestNetplayHostHandover(t *testing.T) {
+	resetNetplay()
+	host, guest, late := netplayConnect(t), netplayConnect(t), netplayConnect(t)
+	netplayEmit(t, host, 1, "open-room", roomRequest("host", "", 3))
+	netplayEmit(t, guest, 2, "join-room", roomRequest("guest", "", 0))
+	netplayEmit(t, late, 3, "join-room", roomRequest("late", "", 0))
+
+	netplayEmit(t, host, 4, "leave-room", nil)
+	room := netplayRoom(t)
+	if room == nil || room.Owner != "guest" || len(room.order) != 2 {
+		t.Fatalf("after host left room = %+v", room)
+	}
+	netplay.Lock()
+	updated := false
+	for _, packet := range late.out {
+		updated = updated || strings.Contains(packet, `"users-updated"`)
+	}
+	netplay.Unlock()
+	if !updated {
+		t.Errorf("remaining players were not told about the new player list")
+	}
+
+	netplay.Lock()
+	handleEioPacket(guest, "41/netplay,") // 네임스페이스 연결 끊기
+	netplay.Unlock()
+	if room := netplayRoom(t); room == nil || room.Owner != "late" {
+		t.Fatalf("after second host left room = %+v", room)
+	}
+	netplayEmit(t, late, 5, "leave-room", nil)
+	if room := netplayRoom(t); room != nil {
+		t.Errorf("empty room was not closed")
+	}
+}
+
+func TestNetplaySignalGoesOnlyToTarget(t *testing.T) {
+	resetNetplay()
+	host, guest, other := netplayConnect(t), netplayConnect(t), netplayConnect(t)
+	netplayEmit(t, host, 1, "open-room", roomRequest("host", "", 3))
+	netplayEmit(t, guest, 2, "join-room", roomRequest("guest", "", 0))
+	netplayEmit(t, other, 3, "join-room", roomRequest("other", "", 0))
+
+	netplay.Lock()
+	guest.out, other.out = nil, nil
+	data, _ := json.Marshal([]interface{}{"webrtc-signal", map[string]string{"target": guest.sockID, "offer": "sdp"}})
+	handleEioPacket(host, "42/netplay,"+string(data))
+	guestOut, otherOut := strings.Join(guest.out, ""), strings.Join(other.out, "")
+	netplay.Unlock()
+
+	if !strings.Contains(guestOut, `"sender":"`+host.sockID+`"`) || strings.Contains(guestOut, `"target"`) {
+		t.Errorf("target received %q, want the signal with sender and without target", guestOut)
+	}
+	if strings.Contains(otherOut, "webrtc-signal") {
+		t.Errorf("signal leaked to another player: %q", otherOut)
+	}
+}
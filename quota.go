@@ -0,0 +1,269 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// [업로드 제한] 라즈베리파이 SD 카드가 가득 차지 않도록 하는 크기/용량 한도
+const (
+	maxSaveBytes       = 8 << 20  // 세이브(SRAM, 메모리카드) 1개
+	maxStateBytes      = 64 << 20 // 상태 저장 1개
+	maxScreenshotBytes = 8 << 20  // 스크린샷 1장
+
+	userQuotaBytes   = 512 << 20 // 사용자별 세이브 + 리비전 + 상태 저장 합계
+	globalQuotaBytes = 4 << 30   // 전체 합계
+	minFreeDiskBytes = 256 << 20 // 업로드 후에도 남겨둘 디스크 여유 공간
+)
+
+// 세이브 이름 → 처음 올린 사용자 (사용자별 용량 계산용)
+var saveOwnersStore = &jsonStore{path: "./data/save_owners.json"}
+
+// [사용량] 업로드마다 폴더 전체를 훑지 않도록 세이브 이름 / 상태 저장 폴더별 크기를 메모리에 두고,
+// 쓰거나 정리한 항목만 다시 잰다. 처음 쓸 때 한 번 전체를 훑고, 파일이 통째로 바뀌면(백업 복원, 롬 이름 변경) 다시 훑는다
+type stateDirUsage struct {
+	total  int64            // 상태 파일 + 메타 + 썸네일
+	byUser map[string]int64 // 사용자 → 상태 파일 + 썸네일
+}
+
+var storageLedger struct {
+	sync.Mutex
+	loaded bool
+	saves  map[string]int64 // 세이브 이름 → 현재본 + 리비전 + 충돌본
+	states map[string]stateDirUsage
+}
+
+// 용량 초과 (507 로 응답)
+type quotaError struct {
+	msg string
+}
+
+func (e *quotaError) Error() string { return e.msg }
+
+// MaxBytesReader 한도를 넘어 읽기에 실패했는지
+func isTooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(err, &maxErr)
+}
+
+// 업로드 오류를 알맞은 상태 코드로 응답 (크기 초과 413, 용량 초과 507, 그 외 fallback)
+func writeUploadError(w http.ResponseWriter, err error, fallback int) {
+	var qerr *quotaError
+	switch {
+	case isTooLarge(err):
+		http.Error(w, "Payload too large", http.StatusRequestEntityTooLarge)
+	case errors.As(err, &qerr):
+		http.Error(w, qerr.msg, http.StatusInsufficientStorage)
+	default:
+		http.Error(w, err.Error(), fallback)
+	}
+}
+
+// 세이브 이름(<sys>-<rom>.sav)을 실제 롬으로 해석. 시스템/롬 이름에 '-' 가 있을 수 있어 모든 위치를 시도
+func parseSaveName(name string) (string, string, bool) {
+	if !strings.HasSuffix(name, ".sav") || filepath.Base(name) != name {
+		return "", "", false
+	}
+	base := strings.TrimSuffix(name, ".sav")
+	for i := 0; i < len(base); i++ {
+		if base[i] != '-' {
+			continue
+		}
+		sys, rom := base[:i], base[i+1:]
+		if sys != "" && rom != "" && romExists(sys, rom) {
+			return sys, rom, true
+		}
+	}
+	return "", "", false
+}
+
+// 내용이 전부 0 인지 (에뮬레이터가 초기화 직후 빈 SRAM 을 내보내는 경우)
+func isAllZero(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func dirSize(dir string) int64 {
+	var total int64
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
+
+func fileSize(path string) int64 {
+	if info, err := os.Stat(path); err == nil {
+		return info.Size()
+	}
+	return 0
+}
+
+func loadSaveOwners() (map[string]string, error) {
+	owners := make(map[string]string)
+	_, err := saveOwnersStore.Load(&owners)
+	return owners, err
+}
+
+// 처음 올린 사용자만 기록 (다른 기기/사용자가 이어서 올려도 용량은 원래 소유자에게)
+func setSaveOwner(name, user string) error {
+	owners := make(map[string]string)
+	err := saveOwnersStore.Update(&owners, func() error {
+		if _, ok := owners[name]; ok {
+			return errNoChange
+		}
+		owners[name] = user
+		return nil
+	})
+	if err == errNoChange {
+		return nil
+	}
+	return err
+}
+
+func saveUsage(name string) int64 {
+	return fileSize(filepath.Join(savesDir, name)) + dirSize(revisionDir(name)) + dirSize(filepath.Join(saveConflictsDir, name))
+}
+
+func stateUsage(dir string) stateDirUsage {
+	u := stateDirUsage{byUser: make(map[string]int64)}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		p := filepath.Join(dir, e.Name())
+		u.total += fileSize(p)
+		if !strings.HasSuffix(p, ".json") {
+			continue
+		}
+		var meta StateSlotMeta
+		if data, err := os.ReadFile(p); err == nil && json.Unmarshal(data, &meta) == nil && meta.User != "" {
+			u.byUser[meta.User] += meta.Size + fileSize(strings.TrimSuffix(p, ".json")+".jpg")
+		}
+	}
+	return u
+}
+
+// storageLedger 잠금 상태에서 호출
+func ensureStorageLedger() {
+	if storageLedger.loaded {
+		return
+	}
+	storageLedger.saves = make(map[string]int64)
+	storageLedger.states = make(map[string]stateDirUsage)
+	names := make(map[string]bool)
+	if entries, err := os.ReadDir(savesDir); err == nil {
+		for _, e := range entries {
+			if !e.IsDir() && strings.HasSuffix(e.Name(), ".sav") {
+				names[e.Name()] = true
+			}
+		}
+	}
+	for _, dir := range []string{saveHistoryDir, saveConflictsDir} {
+		entries, _ := os.ReadDir(dir)
+		for _, e := range entries {
+			if e.IsDir() {
+				names[e.Name()] = true
+			}
+		}
+	}
+	for name := range names {
+		storageLedger.saves[name] = saveUsage(name)
+	}
+	filepath.WalkDir(statesDir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() && path != statesDir {
+			if u := stateUsage(path); u.total > 0 {
+				storageLedger.states[path] = u
+			}
+		}
+		return nil
+	})
+	storageLedger.loaded = true
+}
+
+// 세이브(현재본/리비전/충돌본)를 쓰거나 지운 뒤 호출
+func noteSaveUsage(name string) {
+	storageLedger.Lock()
+	defer storageLedger.Unlock()
+	if storageLedger.loaded {
+		storageLedger.saves[name] = saveUsage(name)
+	}
+}
+
+// 상태 저장 슬롯을 쓰거나 지운 뒤 호출
+func noteStateUsage(dir string) {
+	storageLedger.Lock()
+	defer storageLedger.Unlock()
+	if storageLedger.loaded {
+		storageLedger.states[filepath.Clean(dir)] = stateUsage(dir)
+	}
+}
+
+// 세이브/상태 파일이 한꺼번에 바뀐 뒤(백업 복원, 롬 이름 변경) 다음 확인 때 다시 훑도록 함
+func resetStorageUsage() {
+	storageLedger.Lock()
+	storageLedger.loaded = false
+	storageLedger.Unlock()
+}
+
+// 사용자별 / 전체 사용량 (세이브 현재본 + 리비전 + 충돌본 + 상태 저장)
+func storageUsage(user string) (int64, int64, error) {
+	owners, err := loadSaveOwners()
+
+	storageLedger.Lock()
+	defer storageLedger.Unlock()
+	ensureStorageLedger()
+	var used, total int64
+	for name, size := range storageLedger.saves {
+		total += size
+		if owners[name] == user {
+			used += size
+		}
+	}
+	for _, u := range storageLedger.states {
+		total += u.total
+		used += u.byUser[user]
+	}
+	if err != nil {
+		return 0, total, err
+	}
+	return used, total, nil
+}
+
+// incoming 바이트를 더 써도 되는지 확인
+func checkStorageQuota(user string, incoming int64) error {
+	wd, _ := os.Getwd()
+	if free, total := getDiskUsage(wd); total > 0 && free < uint64(incoming)+minFreeDiskBytes {
+		return &quotaError{fmt.Sprintf("디스크 여유 공간 부족 (남은 공간 %dMB)", free>>20)}
+	}
+	used, total, err := storageUsage(user)
+	if err != nil {
+		return err
+	}
+	if total+incoming > globalQuotaBytes {
+		return &quotaError{fmt.Sprintf("전체 저장 용량 초과 (%dMB / %dMB)", total>>20, int64(globalQuotaBytes)>>20)}
+	}
+	if used+incoming > userQuotaBytes {
+		return &quotaError{fmt.Sprintf("%s 사용자 저장 용량 초과 (%dMB / %dMB)", user, used>>20, int64(userQuotaBytes)>>20)}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+)
+
+// 메모리 합계와 비교할 실제 디스크 사용량
+func walkedStorage() int64 {
+	return dirSize(savesDir) + dirSize(saveHistoryDir) + dirSize(saveConflictsDir) + dirSize(statesDir)
+}
+
+func usageOf(t *testing.T, user string) (int64, int64) {
+	t.Helper()
+	used, total, err := storageUsage(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return used, total
+}
+
+func TestSaveStorageChargedToFirstUploader(t *testing.T) {
+	t.Chdir(t.TempDir())
+	resetStorageUsage()
+	writeTestRom(t, "snes", "Game.sfc", "x")
+	const name = "snes-Game.sfc.sav"
+
+	if w := uploadSave(t, name+"&user=alice", []byte("alice's save"), ""); w.Code != 200 {
+		t.Fatalf("alice upload: status %d (%s)", w.Code, w.Body)
+	}
+	if w := uploadSave(t, name+"&user=bob", []byte("bob continues the same game"), ""); w.Code != 200 {
+		t.Fatalf("bob upload: status %d (%s)", w.Code, w.Body)
+	}
+
+	alice, total := usageOf(t, "alice")
+	bob, _ := usageOf(t, "bob")
+	if alice != saveUsage(name) || alice == 0 {
+		t.Errorf("alice usage = %d, want the whole save (%d)", alice, saveUsage(name))
+	}
+	if bob != 0 {
+		t.Errorf("bob usage = %d, want 0 (alice owns the save)", bob)
+	}
+	if want := walkedStorage(); total != want {
+		t.Errorf("total = %d, want %d", total, want)
+	}
+}
+
+func TestStorageTotalsFollowWritesAndPrunes(t *testing.T) {
+	t.Chdir(t.TempDir())
+	resetStorageUsage()
+	writeTestRom(t, "snes", "Game.sfc", "x")
+	const name = "snes-Game.sfc.sav"
+
+	// 첫 확인에서 한 번 훑은 뒤로는 쓰기/정리 때 갱신한 값만 사용
+	if _, total := usageOf(t, "alice"); total != 0 {
+		t.Fatalf("empty tree total = %d", total)
+	}
+	for i := 0; i < keepRecentRevisions+5; i++ {
+		data := []byte(fmt.Sprintf("save #%d %s", i, bytes.Repeat([]byte{'x'}, i)))
+		if w := uploadSave(t, name+"&user=alice", data, ""); w.Code != 200 {
+			t.Fatalf("upload %d: status %d (%s)", i, w.Code, w.Body)
+		}
+		if _, total := usageOf(t, "alice"); total != walkedStorage() {
+			t.Fatalf("after upload %d total = %d, want %d", i, total, walkedStorage())
+		}
+	}
+	if n := len(listRevisionIDs(name)); n > keepRecentRevisions+1 {
+		t.Fatalf("revisions were not pruned (%d left)", n)
+	}
+
+	before, _ := usageOf(t, "alice")
+	state := bytes.Repeat([]byte{1}, 1000)
+	if _, err := writeStateSlot("snes", "Game.sfc", state, nil, StateSlotMeta{Slot: "1", User: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	after, total := usageOf(t, "alice")
+	if after != before+int64(len(state)) {
+		t.Errorf("after state write alice usage = %d, want %d", after, before+int64(len(state)))
+	}
+	if total != walkedStorage() {
+		t.Errorf("after state write total = %d, want %d", total, walkedStorage())
+	}
+
+	w := httptest.NewRecorder()
+	handleStates(w, httptest.NewRequest("DELETE", "/api/states/snes/Game.sfc/1", nil))
+	if w.Code != 200 {
+		t.Fatalf("delete state: status %d", w.Code)
+	}
+	if used, total := usageOf(t, "alice"); used != before || total != walkedStorage() {
+		t.Errorf("after state delete usage = %d / %d, want %d / %d", used, total, before, walkedStorage())
+	}
+}
+
+func TestZeroedFirstSaveRejected(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeTestRom(t, "snes", "Game.sfc", "x")
+	const name = "snes-Game.sfc.sav"
+
+	if w := uploadSave(t, name, make([]byte, 8192), ""); w.Code != 422 {
+		t.Fatalf("zeroed first upload: status %d, want 422", w.Code)
+	}
+	if revs := loadSaveRevisions(name); len(revs) != 0 {
+		t.Errorf("zeroed upload left revisions: %+v", revs)
+	}
+}
+
+func TestSetSaveOwnerKeepsFirstOwner(t *testing.T) {
+	t.Chdir(t.TempDir())
+	for _, user := range []string{"alice", "bob"} {
+		if err := setSaveOwner("snes-Game.sfc.sav", user); err != nil {
+			t.Fatalf("setSaveOwner(%q) = %v", user, err)
+		}
+	}
+	owners, err := loadSaveOwners()
+	if err != nil || owners["snes-Game.sfc.sav"] != "alice" {
+		t.Errorf("owners = %v, %v; want alice", owners, err)
+	}
+}
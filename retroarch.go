@@ -0,0 +1,358 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// [RetroArch 호환] 실기기(휴대용 기기 등)의 RetroArch 와 세이브를 주고받기 위한 zip 내보내기/가져오기
+// RetroArch 의 "코어별 폴더 정리" 배치를 따른다:
+//
+//	saves/<코어 이름>/<게임>.srm
+//	states/<코어 이름>/<게임>.state, .state1 ~ .state9, .state.auto
+const maxRetroArchImportBytes = 512 << 20
+
+// EmulatorJS 코어 → RetroArch 코어 이름 (세이브 폴더 이름)
+var retroArchCoreNames = map[string]string{
+	"fbneo":           "FinalBurn Neo",
+	"mame2003_plus":   "MAME 2003-Plus",
+	"snes9x":          "Snes9x",
+	"mgba":            "mGBA",
+	"melonds":         "melonDS",
+	"mednafen_psx_hw": "Beetle PSX HW",
+	// [추가] 코어 목록(cores.go)의 나머지 코어
+	"fceumm":           "FCEUmm",
+	"mupen64plus_next": "Mupen64Plus-Next",
+	"gambatte":         "Gambatte",
+	"beetle_vb":        "Beetle VB",
+	"genesis_plus_gx":  "Genesis Plus GX",
+	"picodrive":        "PicoDrive",
+	"yabause":          "Yabause",
+	"stella2014":       "Stella 2014",
+	"a5200":            "a5200",
+	"prosystem":        "ProSystem",
+	"handy":            "Handy",
+	"virtualjaguar":    "Virtual Jaguar",
+	"mednafen_pce":     "Beetle PCE",
+	"mednafen_pcfx":    "Beetle PC-FX",
+	"mednafen_ngp":     "Beetle NeoPop",
+	"mednafen_wswan":   "Beetle WonderSwan",
+	"gearcoleco":       "Gearcoleco",
+	"opera":            "Opera",
+	"ppsspp":           "PPSSPP",
+	"vice_x64sc":       "VICE x64sc",
+	"puae":             "PUAE",
+	"dosbox_pure":      "DOSBox-pure",
+}
+
+func retroArchCoreName(core string) string {
+	if name, ok := retroArchCoreNames[core]; ok {
+		return name
+	}
+	if core == "" {
+		return "Unknown" // 코어를 알 수 없는 폴더
+	}
+	return core
+}
+
+// 상태 슬롯 이름 ↔ RetroArch 확장자. quick/0 은 기본 슬롯(.state)
+func stateSlotToRetroArch(slot string) (string, bool) {
+	switch {
+	case slot == "quick" || slot == "0":
+		return ".state", true
+	case slot == "auto":
+		return ".state.auto", true
+	case len(slot) == 1 && slot[0] >= '1' && slot[0] <= '9':
+		return ".state" + slot, true
+	}
+	return "", false
+}
+
+func retroArchToStateSlot(ext string) (string, bool) {
+	switch {
+	case ext == ".state":
+		return "quick", true
+	case ext == ".state.auto":
+		return "auto", true
+	case len(ext) == 7 && strings.HasPrefix(ext, ".state") && ext[6] >= '1' && ext[6] <= '9':
+		return ext[6:], true
+	}
+	return "", false
+}
+
+// GET /api/saves/export?states=0  → retroarch-saves-<날짜>.zip
+func handleSavesExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	includeStates := r.URL.Query().Get("states") != "0"
+	config := loadConfigFromHTML()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"retroarch-saves-%s.zip\"", time.Now().Format("20060102")))
+	archive := zip.NewWriter(w)
+	defer archive.Close()
+
+	// 다시 가져올 때 서버 쪽과 어느 것이 최신인지 비교할 수 있도록 수정 시각을 유지
+	addFile := func(name, src string) {
+		info, err := os.Stat(src)
+		if err != nil {
+			return
+		}
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return
+		}
+		header := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: info.ModTime()}
+		if f, err := archive.CreateHeader(header); err == nil {
+			f.Write(data)
+		}
+	}
+
+	savesMu.Lock()
+	entries, _ := os.ReadDir(savesDir)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		sys, rom, ok := parseSaveName(e.Name())
+		if !ok {
+			continue
+		}
+		game := strings.TrimSuffix(rom, filepath.Ext(rom))
+		addFile(path.Join("saves", retroArchCoreName(coreForSystem(config, sys)), game+".srm"), filepath.Join(savesDir, e.Name()))
+	}
+	savesMu.Unlock()
+
+	if !includeStates {
+		return
+	}
+	systems, _ := os.ReadDir(statesDir)
+	for _, sysDir := range systems {
+		sys := sysDir.Name()
+		roms, _ := os.ReadDir(filepath.Join(statesDir, sys))
+		for _, romDir := range roms {
+			rom := romDir.Name()
+			if !romExists(sys, rom) {
+				continue
+			}
+			game := strings.TrimSuffix(rom, filepath.Ext(rom))
+			core := coreForSystem(config, sys)
+			for _, slot := range listStateSlots(sys, rom) {
+				ext, ok := stateSlotToRetroArch(slot.Slot)
+				if !ok {
+					continue
+				}
+				addFile(path.Join("states", retroArchCoreName(core), game+ext), filepath.Join(stateSlotDir(sys, rom), slot.Slot+".state"))
+			}
+		}
+	}
+}
+
+type retroArchImportResult struct {
+	Imported  []string          `json:"imported"`
+	Conflicts map[string]string `json:"conflicts"` // zip 내 경로 → 충돌본 id (서버 세이브가 더 최신이거나 시각을 알 수 없음)
+	Skipped   map[string]string `json:"skipped"`   // zip 내 경로 → 사유
+}
+
+// RetroArch 코어 이름 + 게임 이름 → 롬 목록 (같은 코어를 쓰는 시스템이 여럿일 수 있음)
+func retroArchRomIndex(config Config) map[string][]BookmarkItem {
+	index := make(map[string][]BookmarkItem)
+	for sys, roms := range scanRomLibrary(romsDir) {
+		coreName := strings.ToLower(retroArchCoreName(coreForSystem(config, sys)))
+		for _, rom := range roms {
+			game := strings.ToLower(strings.TrimSuffix(rom.Name, filepath.Ext(rom.Name)))
+			item := BookmarkItem{System: sys, Rom: rom.Name}
+			index[coreName+"/"+game] = append(index[coreName+"/"+game], item)
+			// 코어별 폴더 정리를 끈 RetroArch 는 saves/<게임>.srm 으로 저장함
+			index["/"+game] = append(index["/"+game], item)
+		}
+	}
+	return index
+}
+
+// POST /api/saves/import  (본문: zip, 관리자 전용)
+func handleSavesImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	// 여러 게임의 세이브를 한꺼번에 바꿀 수 있으므로 백업 복원과 같이 관리자만
+	if !requireAdmin(w, r) {
+		return
+	}
+	// zip 은 임의 접근이 필요하므로 임시 파일에 받음
+	tmp, err := os.CreateTemp("", "retroarch-import-*.zip")
+	if err != nil {
+		http.Error(w, "Temp file failed", 500)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	size, err := io.Copy(tmp, http.MaxBytesReader(w, r.Body, maxRetroArchImportBytes))
+	if err != nil {
+		writeUploadError(w, err, 400)
+		return
+	}
+	archive, err := zip.NewReader(tmp, size)
+	if err != nil {
+		http.Error(w, "Invalid zip", 400)
+		return
+	}
+
+	var incoming int64
+	for _, f := range archive.File {
+		incoming += int64(f.UncompressedSize64)
+	}
+	user := requestUser(r)
+	if err := checkStorageQuota(user, incoming); err != nil {
+		writeUploadError(w, err, 500)
+		return
+	}
+
+	config := loadConfigFromHTML()
+	index := retroArchRomIndex(config)
+	result := retroArchImportResult{Imported: []string{}, Conflicts: make(map[string]string), Skipped: make(map[string]string)}
+
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		name := path.Clean(strings.ReplaceAll(f.Name, "\\", "/"))
+		parts := strings.Split(name, "/")
+		if len(parts) < 2 || len(parts) > 3 || (parts[0] != "saves" && parts[0] != "states") {
+			result.Skipped[f.Name] = "unknown layout"
+			continue
+		}
+		coreName := ""
+		if len(parts) == 3 {
+			coreName = strings.ToLower(parts[1])
+		}
+		file := parts[len(parts)-1]
+
+		var game, slot string
+		var limit int64
+		if parts[0] == "saves" {
+			if !strings.EqualFold(filepath.Ext(file), ".srm") {
+				result.Skipped[f.Name] = "not .srm"
+				continue
+			}
+			game = strings.TrimSuffix(file, filepath.Ext(file))
+			limit = maxSaveBytes
+		} else {
+			i := strings.Index(strings.ToLower(file), ".state")
+			var ok bool
+			if i > 0 {
+				slot, ok = retroArchToStateSlot(strings.ToLower(file[i:]))
+			}
+			if !ok {
+				result.Skipped[f.Name] = "unknown state slot"
+				continue
+			}
+			game = file[:i]
+			limit = maxStateBytes
+		}
+
+		matches := index[coreName+"/"+strings.ToLower(game)]
+		if len(matches) == 0 {
+			result.Skipped[f.Name] = "no matching rom"
+			continue
+		}
+		if len(matches) > 1 {
+			result.Skipped[f.Name] = "ambiguous rom"
+			continue
+		}
+		target := matches[0]
+		if int64(f.UncompressedSize64) > limit {
+			result.Skipped[f.Name] = "too large"
+			continue
+		}
+		data, err := readZipFile(f, limit)
+		if err != nil || len(data) == 0 {
+			result.Skipped[f.Name] = "unreadable"
+			continue
+		}
+
+		if parts[0] == "saves" {
+			var conflict string
+			conflict, err = importSave(saveFileName(target.System, target.Rom), data, user, f.Modified)
+			if err == nil && conflict != "" {
+				result.Conflicts[f.Name] = conflict
+				continue
+			}
+		} else {
+			if !importedStateIsNewer(target, slot, data, f.Modified) {
+				result.Skipped[f.Name] = "server state is newer"
+				continue
+			}
+			meta := StateSlotMeta{Slot: slot, User: user, Core: coreForSystem(config, target.System)}
+			_, err = writeStateSlot(target.System, target.Rom, data, nil, meta)
+		}
+		if err != nil {
+			logger("retroarch").Warn("가져오기 실패", "file", f.Name, "err", err)
+			result.Skipped[f.Name] = err.Error()
+			continue
+		}
+		result.Imported = append(result.Imported, target.System+"/"+target.Rom+" ← "+f.Name)
+	}
+
+	if len(result.Imported) > 0 {
+		invalidateLibraryIndex()
+		invalidateIndexCache()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func readZipFile(f *zip.File, limit int64) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(io.LimitReader(rc, limit))
+}
+
+// 세이브 업로드와 같은 규칙으로 저장 (빈 세이브 거부, 리비전 기록).
+// 서버 세이브와 내용이 다르면 zip 항목이 더 최신일 때만 덮어쓰고(이전 세이브는 리비전으로 남음),
+// 아니면 현재 세이브는 두고 충돌본으로 보관해 그 id 를 돌려준다 (/api/save/resolve 로 선택)
+func importSave(name string, data []byte, user string, modified time.Time) (string, error) {
+	savesMu.Lock()
+	defer savesMu.Unlock()
+	if isAllZero(data) {
+		return "", fmt.Errorf("zeroed save")
+	}
+	current := filepath.Join(savesDir, name)
+	if old, err := os.ReadFile(current); err == nil && md5Hex(old) != md5Hex(data) {
+		info, err := os.Stat(current)
+		if err != nil || modified.IsZero() || !modified.After(info.ModTime()) {
+			id, err := storeSaveConflict(name, data)
+			if err == nil {
+				logger("retroarch").Warn("서버 세이브가 더 최신이라 충돌본으로 보관", "name", name, "conflict", id)
+			}
+			return id, err
+		}
+	}
+	if _, err := storeSaveRevision(name, data); err != nil {
+		return "", err
+	}
+	return "", setSaveOwner(name, user)
+}
+
+// 같은 슬롯에 다른 상태 파일이 있으면 zip 항목이 더 최신일 때만 덮어씀 (상태 저장은 리비전이 없음)
+func importedStateIsNewer(target BookmarkItem, slot string, data []byte, modified time.Time) bool {
+	meta, err := readStateMeta(stateSlotDir(target.System, target.Rom), slot)
+	if err != nil || meta.MD5 == md5Hex(data) {
+		return true
+	}
+	return !modified.IsZero() && modified.Unix() > meta.Updated
+}
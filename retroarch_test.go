@@ -0,0 +1,150 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type zipEntry struct {
+	name     string
+	data     []byte
+	modified time.Time
+}
+
+func importZip(t *testing.T, entries ...zipEntry) (*httptest.ResponseRecorder, retroArchImportResult) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: e.name, Method: zip.Deflate, Modified: e.modified})
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write(e.data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	handleSavesImport(w, httptest.NewRequest("POST", "/api/saves/import", &buf))
+	var result retroArchImportResult
+	if w.Code == 200 {
+		if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w, result
+}
+
+func TestSavesImportRequiresAdmin(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv(adminTokenEnv, "")
+	t.Setenv(adminOpenEnv, "")
+	writeTestRom(t, "snes", "Game.sfc", "x")
+
+	if w, _ := importZip(t, zipEntry{"saves/Game.srm", []byte("device"), time.Now()}); w.Code != 403 {
+		t.Fatalf("import without admin: status %d, want 403", w.Code)
+	}
+	if _, err := os.Stat(filepath.Join(savesDir, "snes-Game.sfc.sav")); err == nil {
+		t.Errorf("save was written without admin")
+	}
+}
+
+func TestSavesImportDoesNotOverwriteNewerServerSave(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv(adminTokenEnv, "")
+	t.Setenv(adminOpenEnv, "1")
+	writeTestRom(t, "snes", "Game.sfc", "x")
+	const name = "snes-Game.sfc.sav"
+	server := []byte("played on the web today")
+	if w := uploadSave(t, name, server, ""); w.Code != 200 {
+		t.Fatalf("upload: status %d (%s)", w.Code, w.Body)
+	}
+
+	w, result := importZip(t, zipEntry{"saves/Game.srm", []byte("old handheld save"), time.Now().Add(-time.Hour)})
+	if w.Code != 200 {
+		t.Fatalf("import: status %d (%s)", w.Code, w.Body)
+	}
+	id := result.Conflicts["saves/Game.srm"]
+	if id == "" || len(result.Imported) != 0 {
+		t.Fatalf("result = %+v, want the older entry kept as a conflict", result)
+	}
+	if got := currentSave(t, name); !bytes.Equal(got, server) {
+		t.Errorf("current save = %q, want the newer server save", got)
+	}
+	kept, err := os.ReadFile(filepath.Join(saveConflictsDir, name, id+".sav"))
+	if err != nil || string(kept) != "old handheld save" {
+		t.Errorf("conflict copy = %q, %v", kept, err)
+	}
+
+	// 시각이 없는 항목도 덮어쓰지 않음
+	if _, result := importZip(t, zipEntry{"saves/Game.srm", []byte("undated"), time.Time{}}); result.Conflicts["saves/Game.srm"] == "" {
+		t.Errorf("undated entry: result = %+v, want a conflict", result)
+	}
+}
+
+func TestSavesImportNewerEntryKeepsServerSaveAsRevision(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv(adminTokenEnv, "")
+	t.Setenv(adminOpenEnv, "1")
+	writeTestRom(t, "snes", "Game.sfc", "x")
+	const name = "snes-Game.sfc.sav"
+	server := []byte("web save")
+	if w := uploadSave(t, name, server, ""); w.Code != 200 {
+		t.Fatalf("upload: status %d (%s)", w.Code, w.Body)
+	}
+
+	device := []byte("handheld save from tonight")
+	_, result := importZip(t,
+		zipEntry{"saves/Game.srm", device, time.Now().Add(time.Hour)},
+		zipEntry{"saves/Game2.srm", []byte("no rom"), time.Now()},
+	)
+	if len(result.Imported) != 1 || len(result.Conflicts) != 0 {
+		t.Fatalf("result = %+v, want the newer entry imported", result)
+	}
+	if result.Skipped["saves/Game2.srm"] != "no matching rom" {
+		t.Errorf("skipped = %+v", result.Skipped)
+	}
+	if got := currentSave(t, name); !bytes.Equal(got, device) {
+		t.Errorf("current save = %q, want %q", got, device)
+	}
+	found := false
+	for _, rev := range loadSaveRevisions(name) {
+		if rev.MD5 == md5Hex(server) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("overwritten server save is missing from revisions")
+	}
+
+	if _, result := importZip(t, zipEntry{"saves/Game.srm", make([]byte, 64), time.Now().Add(2 * time.Hour)}); result.Skipped["saves/Game.srm"] == "" {
+		t.Errorf("zeroed entry: result = %+v, want skipped", result)
+	}
+}
+
+func TestStateImportSkipsNewerServerState(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv(adminTokenEnv, "")
+	t.Setenv(adminOpenEnv, "1")
+	writeTestRom(t, "snes", "Game.sfc", "x")
+	server := []byte("server state")
+	if _, err := writeStateSlot("snes", "Game.sfc", server, nil, StateSlotMeta{Slot: "1", User: defaultUser}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, result := importZip(t, zipEntry{"states/Game.state1", []byte("older device state"), time.Now().Add(-time.Hour)})
+	if result.Skipped["states/Game.state1"] != "server state is newer" {
+		t.Fatalf("result = %+v, want the older state skipped", result)
+	}
+	data, _ := os.ReadFile(filepath.Join(stateSlotDir("snes", "Game.sfc"), "1.state"))
+	if !bytes.Equal(data, server) {
+		t.Errorf("state = %q, want the server state", data)
+	}
+}
@@ -0,0 +1,546 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// [롬 관리] 이름 바꾸기 / 다른 시스템 폴더로 옮기기 / 일괄 작업
+// 파일을 옮기면 sys/rom 으로 묶인 기록(즐겨찾기, 컬렉션, 세이브와 리비전, 상태 저장, 인젝트 기록,
+// 플레이 통계, 메타데이터, 썸네일/박스아트)도 새 이름으로 옮긴다
+
+// 관리 API 는 X-Admin-Token 헤더(또는 ?token=)가 RETRO_ADMIN_TOKEN 과 같아야 한다.
+// 토큰이 없으면 관리 API 를 막으며(403), 집 안에서만 쓰는 서버라도 토큰 없이 열려면 RETRO_ADMIN_OPEN=1 을 명시해야 한다
+const (
+	adminTokenEnv = "RETRO_ADMIN_TOKEN"
+	adminOpenEnv  = "RETRO_ADMIN_OPEN"
+)
+
+var (
+	romOpsMu         sync.Mutex
+	romMetadataStore = &jsonStore{path: metadataFile}
+)
+
+// 코어 목록에도 coreMap 에도 없는 시스템 폴더로는 옮기지 않음 (임의의 최상위 폴더가 생기지 않도록)
+var errUnknownSystem = errors.New("unknown system")
+
+// 옮겨 갈 시스템이 읽지 않는 확장자 (옮기고 나면 목록에서 사라짐)
+var errBadExtension = errors.New("extension not allowed for system")
+
+// 가상 재생 목록의 이름은 디스크 파일 이름에서 정해지므로 이름만 바꿀 수 없음
+var errVirtualRename = errors.New("virtual playlist cannot be renamed")
+
+type RomRelocation struct {
+	From    BookmarkItem `json:"from"`
+	To      BookmarkItem `json:"to"`
+	Updated []string     `json:"updated"`          // 함께 옮긴 기록 종류
+	Failed  []string     `json:"failed,omitempty"` // 롬은 옮겼지만 옮기지 못한 파일/기록 ("종류: 오류")
+}
+
+type RomVerifyResult struct {
+	System  string `json:"system"`
+	Rom     string `json:"rom"`
+	OK      bool   `json:"ok"`
+	Size    int64  `json:"size"`
+	Entries int    `json:"entries,omitempty"` // zip 내 파일 수
+	Error   string `json:"error,omitempty"`
+}
+
+type RomBatchRequest struct {
+	Action   string         `json:"action"` // delete | move | verify
+	Items    []BookmarkItem `json:"items"`
+	ToSystem string         `json:"toSystem,omitempty"`
+}
+
+type RomBatchResult struct {
+	System string      `json:"system"`
+	Rom    string      `json:"rom"`
+	OK     bool        `json:"ok"`
+	Error  string      `json:"error,omitempty"`
+	Result interface{} `json:"result,omitempty"`
+}
+
+func adminOpen() bool {
+	v := strings.ToLower(os.Getenv(adminOpenEnv))
+	return v == "1" || v == "true"
+}
+
+// 시작할 때 관리 API 상태를 알림
+func logAdminPolicy() {
+	switch {
+	case os.Getenv(adminTokenEnv) != "":
+	case adminOpen():
+		logger("admin").Warn("관리 API 가 토큰 없이 열려 있음", "env", adminOpenEnv)
+	default:
+		logger("admin").Warn("관리 API 비활성화 (토큰 미설정)", "env", adminTokenEnv)
+	}
+}
+
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	token := os.Getenv(adminTokenEnv)
+	if token == "" {
+		if adminOpen() {
+			return true
+		}
+		http.Error(w, "Admin API disabled: set "+adminTokenEnv+" (or "+adminOpenEnv+"=1 to allow without a token)", 403)
+		return false
+	}
+	given := r.Header.Get("X-Admin-Token")
+	if given == "" {
+		given = r.URL.Query().Get("token")
+	}
+	if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		http.Error(w, "Unauthorized", 401)
+		return false
+	}
+	return true
+}
+
+// 새 롬 파일 이름 / 시스템 폴더 이름 검증
+func validRomName(name string) bool {
+	return name != "" && filepath.Base(name) == name && !strings.HasPrefix(name, ".")
+}
+
+// 롬 파일을 옮기고 관련 기록을 새 키로 다시 씀
+func relocateRom(sys, rom, newSys, newRom string) (RomRelocation, error) {
+	romOpsMu.Lock()
+	defer romOpsMu.Unlock()
+
+	result := RomRelocation{From: BookmarkItem{System: sys, Rom: rom}, To: BookmarkItem{System: newSys, Rom: newRom}, Updated: []string{}}
+	if !romExists(sys, rom) {
+		return result, os.ErrNotExist
+	}
+	if sys == newSys && rom == newRom {
+		return result, nil
+	}
+	if _, ok := lookupSystem(loadConfigFromHTML(), newSys); newSys != sys && !ok {
+		return result, errUnknownSystem
+	}
+	if !romExtensions(loadScanSettings(), newSys)[strings.ToLower(filepath.Ext(newRom))] {
+		return result, errBadExtension
+	}
+	// 같은 시스템 안에서 이름만 바꾸면 원래(하위) 폴더에 두고, 다른 시스템으로 옮기면 그 시스템 폴더 바로 아래로
+	src, _ := resolveRomPath(sys, rom)
+	virtual := isVirtualPlaylist(src)
+	if virtual && newRom != rom {
+		return result, errVirtualRename
+	}
+	dest := filepath.Join(romsDir, newSys, newRom)
+	if newSys == sys {
+		dest = filepath.Join(filepath.Dir(src), newRom)
+	}
+	if _, err := os.Stat(dest); err == nil || romExists(newSys, newRom) {
+		return result, os.ErrExist
+	}
+	// 재생 목록(.m3u)/cue 를 다른 폴더로 옮길 때는 함께 필요한 파일(디스크, 트랙)도 같이 옮김 (같은 폴더 기준 상대 경로)
+	var companionMoves [][2]string
+	if filepath.Dir(src) != filepath.Dir(dest) {
+		for _, rel := range romCompanions(src) {
+			from := filepath.Join(filepath.Dir(src), filepath.FromSlash(rel))
+			to := filepath.Join(filepath.Dir(dest), filepath.FromSlash(rel))
+			if _, err := os.Stat(to); err == nil {
+				return result, os.ErrExist
+			}
+			companionMoves = append(companionMoves, [2]string{from, to})
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return result, err
+	}
+	// 가상 재생 목록은 디스크만 옮기면 옮겨 간 폴더에서 다시 묶임
+	if !virtual {
+		if err := moveFile(src, dest); err != nil {
+			return result, err
+		}
+	}
+	for _, m := range companionMoves {
+		err := os.MkdirAll(filepath.Dir(m[1]), 0755)
+		if err == nil {
+			err = moveFile(m[0], m[1])
+		}
+		if err != nil {
+			logger("romops").Error("함께 필요한 파일 이동 실패", "from", m[0], "to", m[1], "err", err)
+			result.Failed = append(result.Failed, "companion "+filepath.Base(m[0])+": "+err.Error())
+		}
+	}
+	forgetRomLocations(sys, newSys)
+
+	note := func(what string, err error) {
+		if err == errNoChange {
+			return
+		}
+		if err != nil {
+			logger("romops").Error("관련 기록 갱신 실패", "what", what, "from", sys+"/"+rom, "to", newSys+"/"+newRom, "err", err)
+			result.Failed = append(result.Failed, what+": "+err.Error())
+			return
+		}
+		result.Updated = append(result.Updated, what)
+	}
+	same := func(b BookmarkItem) bool { return b.System == sys && b.Rom == rom }
+
+	var bookmarks []BookmarkItem
+	note("bookmarks", bookmarkStore.Update(&bookmarks, func() error {
+		changed := false
+		for i := range bookmarks {
+			if same(bookmarks[i]) {
+				bookmarks[i] = result.To
+				changed = true
+			}
+		}
+		if !changed {
+			return errNoChange
+		}
+		return nil
+	}))
+
+	collectionsMu.Lock()
+	cols, err := loadCollections()
+	if err == nil {
+		err = errNoChange
+		for i := range cols {
+			for j := range cols[i].Items {
+				if same(cols[i].Items[j]) {
+					cols[i].Items[j] = result.To
+					err = nil
+				}
+			}
+		}
+		if err == nil {
+			err = saveCollections(cols)
+		}
+	}
+	collectionsMu.Unlock()
+	note("collections", err)
+
+	note("saves", renameSaveData(saveFileName(sys, rom), saveFileName(newSys, newRom)))
+	if _, err := os.Stat(stateSlotDir(sys, rom)); err == nil {
+		note("states", renameIfExists(stateSlotDir(sys, rom), stateSlotDir(newSys, newRom)))
+	}
+
+	injectLog := make(InjectLog)
+	note("injectLog", injectLogStore.Update(&injectLog, func() error {
+		v, ok := injectLog[sys+"/"+rom]
+		if !ok {
+			return errNoChange
+		}
+		delete(injectLog, sys+"/"+rom)
+		injectLog[newSys+"/"+newRom] = v
+		return nil
+	}))
+
+	note("playStats", renamePlayStats(sys, rom, newSys, newRom))
+
+	meta := make(map[string]RomMeta)
+	note("metadata", romMetadataStore.Update(&meta, func() error {
+		m, ok := meta[sys+"/"+rom]
+		if !ok {
+			return errNoChange
+		}
+		delete(meta, sys+"/"+rom)
+		meta[newSys+"/"+newRom] = m
+		return nil
+	}))
+
+	oldImages, newImages := romArtifacts(sys, rom), romArtifacts(newSys, newRom)
+	oldNoExt := strings.TrimSuffix(rom, filepath.Ext(rom))
+	newNoExt := strings.TrimSuffix(newRom, filepath.Ext(newRom))
+	for _, ext := range boxartExts {
+		oldImages = append(oldImages, filepath.Join(boxartDir, sys, oldNoExt+ext))
+		newImages = append(newImages, filepath.Join(boxartDir, newSys, newNoExt+ext))
+	}
+	for i := range oldImages {
+		if _, err := os.Stat(oldImages[i]); err == nil {
+			note("images", renameIfExists(oldImages[i], newImages[i]))
+		}
+	}
+
+	onPlayStatsChanged()
+	resetStorageUsage()
+	invalidateLibraryIndex()
+	invalidateIndexCache()
+	logger("romops").Info("롬 이동", "from", sys+"/"+rom, "to", newSys+"/"+newRom, "updated", result.Updated)
+	return result, nil
+}
+
+// Update 의 fn 이 돌려주면 쓰지 않고 넘어가는 값 (바꿀 것이 없을 때)
+var errNoChange = fmt.Errorf("no change")
+
+func renameIfExists(src, dst string) error {
+	if _, err := os.Stat(src); err != nil {
+		return nil
+	}
+	if _, err := os.Stat(dst); err == nil {
+		return os.ErrExist
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return os.Rename(src, dst)
+}
+
+// 세이브 현재본 + 리비전 + 충돌본 + 소유자 기록. 옮길 것이 없으면 errNoChange
+func renameSaveData(oldName, newName string) error {
+	savesMu.Lock()
+	defer savesMu.Unlock()
+	moved := false
+	for _, dir := range []string{savesDir, saveHistoryDir, saveConflictsDir} {
+		src := filepath.Join(dir, oldName)
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		if err := renameIfExists(src, filepath.Join(dir, newName)); err != nil {
+			return err
+		}
+		moved = true
+	}
+	owners := make(map[string]string)
+	err := saveOwnersStore.Update(&owners, func() error {
+		owner, ok := owners[oldName]
+		if !ok {
+			return errNoChange
+		}
+		delete(owners, oldName)
+		owners[newName] = owner
+		return nil
+	})
+	if err == errNoChange && moved {
+		return nil
+	}
+	return err
+}
+
+// 통계와 진행 중인 세션의 키를 바꿈. 호출 후 onPlayStatsChanged 필요
+func renamePlayStats(sys, rom, newSys, newRom string) error {
+	playSessions.Lock()
+	defer playSessions.Unlock()
+	ensurePlayStatsLoaded()
+	if !playSessions.loaded {
+		return fmt.Errorf("통계 파일을 읽지 못함")
+	}
+	for key, st := range playSessions.stats {
+		if st.System != sys || st.Rom != rom {
+			continue
+		}
+		delete(playSessions.stats, key)
+		st.System, st.Rom = newSys, newRom
+		newKey := playStatKey(st.User, newSys, newRom)
+		if prev := playSessions.stats[newKey]; prev != nil {
+			// 옮겨 갈 자리에 이미 기록이 있으면 합침
+			st.TotalSeconds += prev.TotalSeconds
+			st.PlayCount += prev.PlayCount
+			if prev.LastPlayed > st.LastPlayed {
+				st.LastPlayed = prev.LastPlayed
+			}
+		}
+		playSessions.stats[newKey] = st
+	}
+	for _, sess := range playSessions.active {
+		if sess.System == sys && sess.Rom == rom {
+			sess.System, sess.Rom = newSys, newRom
+		}
+	}
+	savePlayStats()
+	return nil
+}
+
+// 파일이 읽히는지, zip 이면 모든 항목의 CRC 가 맞는지 확인
+func verifyRom(sys, rom string) RomVerifyResult {
+	result := RomVerifyResult{System: sys, Rom: rom}
+	path, _ := resolveRomPath(sys, rom)
+	// 가상 재생 목록은 디스크 파일을 모두 읽어 봄
+	if isVirtualPlaylist(path) {
+		for _, rel := range romCompanions(path) {
+			f, err := os.Open(filepath.Join(filepath.Dir(path), filepath.FromSlash(rel)))
+			if err == nil {
+				var n int64
+				n, err = io.Copy(io.Discard, f)
+				result.Size += n
+				f.Close()
+			}
+			if err != nil {
+				result.Error = rel + ": " + err.Error()
+				return result
+			}
+			result.Entries++
+		}
+		result.OK = true
+		return result
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		result.Error = "not found"
+		return result
+	}
+	result.Size = info.Size()
+	if !strings.EqualFold(filepath.Ext(rom), ".zip") {
+		f, err := os.Open(path)
+		if err == nil {
+			_, err = io.Copy(io.Discard, f)
+			f.Close()
+		}
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		result.OK = true
+		return result
+	}
+
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer archive.Close()
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err == nil {
+			_, err = io.Copy(io.Discard, rc)
+			rc.Close()
+		}
+		if err != nil {
+			result.Error = f.Name + ": " + err.Error()
+			return result
+		}
+		result.Entries++
+	}
+	result.OK = true
+	return result
+}
+
+func writeRelocateError(w http.ResponseWriter, err error) {
+	switch {
+	case os.IsNotExist(err):
+		http.Error(w, "Unknown rom", 404)
+	case os.IsExist(err):
+		http.Error(w, "같은 이름의 롬이 이미 있습니다", http.StatusConflict)
+	case errors.Is(err, errUnknownSystem):
+		http.Error(w, "Unknown system", 400)
+	case errors.Is(err, errBadExtension):
+		http.Error(w, "이 시스템에서 읽지 않는 확장자입니다", 400)
+	case errors.Is(err, errVirtualRename):
+		http.Error(w, "자동으로 묶은 재생 목록은 디스크 파일 이름을 바꾸세요", 400)
+	default:
+		http.Error(w, "Move failed", 500)
+	}
+}
+
+// POST /api/rom/rename?sys=&rom=&name=      같은 시스템 안에서 파일 이름 변경
+// POST /api/rom/move?sys=&rom=&to=&name=    다른 시스템 폴더로 이동 (name 을 주면 이름도 변경)
+func handleRomRelocate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+	q := r.URL.Query()
+	sys, rom := filepath.Base(q.Get("sys")), filepath.Base(q.Get("rom"))
+	newSys, newRom := sys, rom
+	if name := q.Get("name"); name != "" {
+		newRom = name
+	}
+	if strings.HasSuffix(r.URL.Path, "/move") {
+		newSys = q.Get("to")
+	} else if q.Get("name") == "" {
+		http.Error(w, "Missing name", 400)
+		return
+	}
+	if !validRomName(sys) || !validRomName(rom) || !validRomName(newSys) || !validRomName(newRom) {
+		http.Error(w, "Invalid params", 400)
+		return
+	}
+
+	result, err := relocateRom(sys, rom, newSys, newRom)
+	if err != nil {
+		writeRelocateError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// POST /api/rom/batch {action: delete|move|verify, items: [{system, rom}], toSystem}
+func handleRomBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+	var req RomBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Items) == 0 {
+		http.Error(w, "Invalid JSON", 400)
+		return
+	}
+	switch req.Action {
+	case "delete", "verify":
+	case "move":
+		if !validRomName(req.ToSystem) {
+			http.Error(w, "Missing toSystem", 400)
+			return
+		}
+		if _, ok := lookupSystem(loadConfigFromHTML(), req.ToSystem); !ok {
+			http.Error(w, "Unknown system", 400)
+			return
+		}
+	default:
+		http.Error(w, "Unknown action", 400)
+		return
+	}
+
+	user := requestUser(r)
+	results := []RomBatchResult{}
+	for _, item := range req.Items {
+		res := RomBatchResult{System: item.System, Rom: item.Rom}
+		if !validRomName(item.System) || !validRomName(item.Rom) {
+			res.Error = "invalid name"
+			results = append(results, res)
+			continue
+		}
+		switch req.Action {
+		case "delete":
+			if !romExists(item.System, item.Rom) {
+				res.Error = "not found"
+				break
+			}
+			trashed, err := moveRomToTrash(item.System, item.Rom, user)
+			if err != nil {
+				res.Error = err.Error()
+				break
+			}
+			res.OK, res.Result = true, trashed
+		case "move":
+			moved, err := relocateRom(item.System, item.Rom, req.ToSystem, item.Rom)
+			if err != nil {
+				res.Error = err.Error()
+				break
+			}
+			// 롬은 옮겼지만 일부 기록/파일이 남았으면 실패로 표시하고 내역을 함께 돌려줌
+			res.OK, res.Result = len(moved.Failed) == 0, moved
+			if !res.OK {
+				res.Error = "partially moved: " + strings.Join(moved.Failed, "; ")
+			}
+		case "verify":
+			v := verifyRom(item.System, item.Rom)
+			res.OK, res.Error, res.Result = v.OK, v.Error, v
+		}
+		results = append(results, res)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
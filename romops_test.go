@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		open   string
+		header string
+		ok     bool
+		status int
+	}{
+		{"no token configured", "", "", "", false, 403},
+		{"no token configured, header sent", "", "", "anything", false, 403},
+		{"explicitly open", "", "1", "", true, 200},
+		{"missing header", "secret", "", "", false, 401},
+		{"wrong header", "secret", "", "guess", false, 401},
+		{"open does not bypass a configured token", "secret", "1", "guess", false, 401},
+		{"correct header", "secret", "", "secret", true, 200},
+	}
+	for _, tt := range tests {
+		t.Setenv(adminTokenEnv, tt.token)
+		t.Setenv(adminOpenEnv, tt.open)
+		r := httptest.NewRequest("POST", "/api/rom/rename", nil)
+		if tt.header != "" {
+			r.Header.Set("X-Admin-Token", tt.header)
+		}
+		w := httptest.NewRecorder()
+		if ok := requireAdmin(w, r); ok != tt.ok || w.Code != tt.status {
+			t.Errorf("%s: requireAdmin = %v (status %d), want %v (status %d)", tt.name, ok, w.Code, tt.ok, tt.status)
+		}
+	}
+}
+
+func TestRelocateRomChecksTargetExtension(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeTestRom(t, "snes", "Game.sfc", "rom")
+
+	tests := []struct {
+		newSys, newRom string
+		want           error
+	}{
+		{"snes", "Game.txt", errBadExtension},
+		{"nes", "Game.sfc", errBadExtension},
+		{"snes", "Game (USA).sfc", nil},
+	}
+	for _, tt := range tests {
+		_, err := relocateRom("snes", "Game.sfc", tt.newSys, tt.newRom)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("relocateRom to %s/%s = %v, want %v", tt.newSys, tt.newRom, err, tt.want)
+		}
+	}
+	if !romExists("snes", "Game (USA).sfc") || romExists("snes", "Game.sfc") {
+		t.Errorf("valid rename did not move the rom")
+	}
+}
+
+func TestRomBatchMoveReportsPartialFailure(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv(adminTokenEnv, "")
+	t.Setenv(adminOpenEnv, "1")
+	writeTestRom(t, "fbneo", "game.zip", "rom")
+	// 읽을 수 없는 즐겨찾기 파일: 롬은 옮겨지지만 즐겨찾기는 옮기지 못함
+	if err := os.WriteFile(bookmarkStore.path, []byte("{broken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := json.Marshal(RomBatchRequest{Action: "move", Items: []BookmarkItem{{System: "fbneo", Rom: "game.zip"}}, ToSystem: "mame"})
+	w := httptest.NewRecorder()
+	handleRomBatch(w, httptest.NewRequest("POST", "/api/rom/batch", bytes.NewReader(body)))
+	if w.Code != 200 {
+		t.Fatalf("batch: status %d (%s)", w.Code, w.Body)
+	}
+	var results []struct {
+		OK     bool          `json:"ok"`
+		Error  string        `json:"error"`
+		Result RomRelocation `json:"result"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &results); err != nil || len(results) != 1 {
+		t.Fatalf("results = %s, %v", w.Body, err)
+	}
+	res := results[0]
+	if res.OK || !strings.HasPrefix(res.Error, "partially moved: bookmarks") {
+		t.Errorf("result ok=%v error=%q, want a partial failure naming bookmarks", res.OK, res.Error)
+	}
+	if len(res.Result.Failed) == 0 || !romExists("mame", "game.zip") {
+		t.Errorf("failed = %q, rom moved = %v", res.Result.Failed, romExists("mame", "game.zip"))
+	}
+}
@@ -0,0 +1,445 @@
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	saveHistoryDir   = "./data/saves_history"
+	saveConflictsDir = "./data/saves_conflicts"
+
+	// [보관 정책] 최근 N개 + 최근 며칠간 하루 1개 + 최근 몇 주간 주 1개
+	keepRecentRevisions = 10
+	keepDailyRevisions  = 7
+	keepWeeklyRevisions = 4
+
+	revisionTimeFormat = "20060102-150405.000000"
+)
+
+// 세이브 쓰기(현재본 + 리비전)를 직렬화
+var savesMu sync.Mutex
+
+type SaveRevision struct {
+	ID        string `json:"id"`
+	Time      int64  `json:"time"`
+	Size      int64  `json:"size"`
+	MD5       string `json:"md5"`
+	SizeDelta int64  `json:"sizeDelta"` // 바로 이전(더 오래된) 리비전 대비 크기 변화
+	Current   bool   `json:"current"`   // 현재 세이브와 내용이 같은지
+}
+
+func revisionDir(name string) string {
+	return filepath.Join(saveHistoryDir, name)
+}
+
+func md5Hex(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// savesMu 잠금 상태에서 호출. 현재 세이브를 교체하고 리비전으로도 남김
+// 현재 세이브와 내용이 같으면(주기적 자동 저장) 아무것도 하지 않음
+func storeSaveRevision(name string, data []byte) (string, error) {
+	current := filepath.Join(savesDir, name)
+	old, err := os.ReadFile(current)
+	if err == nil && bytes.Equal(old, data) {
+		return "", nil
+	}
+	// 기록 없이 있던 세이브(리비전 기능 이전, 직접 복사한 파일 등)는 덮어쓰기 전에 리비전으로 보관
+	if err == nil {
+		if err := archiveCurrentSave(name, current, old); err != nil {
+			return "", err
+		}
+	}
+
+	rev := time.Now().Format(revisionTimeFormat)
+	if err := writeFileAtomic(filepath.Join(revisionDir(name), rev+".sav"), data, false); err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(current, data, false); err != nil {
+		return "", err
+	}
+	pruneSaveRevisions(name)
+	noteSaveUsage(name)
+	// 기존 세이브를 덮어쓰면 폴더 시각이 바뀌지 않으므로 검색 인덱스(세이브 크기, 최근 플레이)를 직접 무효화
+	invalidateLibraryIndex()
+	return rev, nil
+}
+
+// 현재 세이브와 같은 내용의 리비전이 없으면 파일 수정 시각을 ID 로 보관 (savesMu 잠금 상태에서 호출)
+// 보통은 가장 최근 리비전이 현재 세이브이므로 첫 번째 비교에서 끝남
+func archiveCurrentSave(name, current string, data []byte) error {
+	sum := md5Hex(data)
+	for _, id := range listRevisionIDs(name) {
+		if rev, err := os.ReadFile(filepath.Join(revisionDir(name), id+".sav")); err == nil && md5Hex(rev) == sum {
+			return nil
+		}
+	}
+	modTime := time.Now()
+	if info, err := os.Stat(current); err == nil {
+		modTime = info.ModTime()
+	}
+	path := filepath.Join(revisionDir(name), modTime.Format(revisionTimeFormat)+".sav")
+	if _, err := os.Stat(path); err == nil {
+		// 같은 시각의 다른 리비전이 있으면 지금 시각으로 (내용은 잃지 않음)
+		path = filepath.Join(revisionDir(name), time.Now().Add(-time.Microsecond).Format(revisionTimeFormat)+".sav")
+	}
+	logger("saves").Info("기록 없는 세이브를 리비전으로 보관", "name", name, "path", path)
+	return writeFileAtomic(path, data, false)
+}
+
+// [충돌] 기준 버전(If-Match)이 현재 세이브와 다른 업로드는 현재본을 바꾸지 않고 따로 보관
+// savesMu 잠금 상태에서 호출
+func storeSaveConflict(name string, data []byte) (string, error) {
+	id := time.Now().Format(revisionTimeFormat)
+	if err := writeFileAtomic(filepath.Join(saveConflictsDir, name, id+".sav"), data, false); err != nil {
+		return "", err
+	}
+	noteSaveUsage(name)
+	return id, nil
+}
+
+// 현재 세이브의 ETag (내용 MD5). 세이브가 없으면 ""
+func currentSaveETag(name string) string {
+	data, err := os.ReadFile(filepath.Join(savesDir, name))
+	if err != nil {
+		return ""
+	}
+	return md5Hex(data)
+}
+
+// If-Match 값이 현재 ETag 와 맞는지. 헤더가 없거나 "*" 이면 통과 (이전 클라이언트 호환)
+func saveETagMatches(ifMatch, current string) bool {
+	if ifMatch == "" || ifMatch == "*" || current == "" {
+		return true
+	}
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if strings.Trim(tag, `"`) == current {
+			return true
+		}
+	}
+	return false
+}
+
+func listConflictIDs(name string) []string {
+	return listSavFiles(filepath.Join(saveConflictsDir, name))
+}
+
+// 리비전 ID(파일명) 목록, 최신순
+func listRevisionIDs(name string) []string {
+	return listSavFiles(revisionDir(name))
+}
+
+func listSavFiles(dir string) []string {
+	entries, _ := os.ReadDir(dir)
+	var ids []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".sav") {
+			ids = append(ids, strings.TrimSuffix(e.Name(), ".sav"))
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	return ids
+}
+
+func parseRevisionTime(id string) time.Time {
+	t, err := time.ParseInLocation(revisionTimeFormat, id, time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// 보관 정책 밖의 리비전 삭제
+func pruneSaveRevisions(name string) {
+	ids := listRevisionIDs(name)
+	keep := make(map[string]bool)
+	days := make(map[string]bool)
+	weeks := make(map[string]bool)
+	now := time.Now()
+
+	for i, id := range ids {
+		if i < keepRecentRevisions {
+			keep[id] = true
+		}
+		t := parseRevisionTime(id)
+		// 최신순으로 순회하므로 각 날짜/주의 첫 리비전이 그 기간의 마지막 저장본
+		day := t.Format("2006-01-02")
+		if now.Sub(t) < keepDailyRevisions*24*time.Hour && !days[day] {
+			days[day] = true
+			keep[id] = true
+		}
+		year, week := t.ISOWeek()
+		weekKey := fmt.Sprintf("%d-%02d", year, week)
+		if now.Sub(t) < keepWeeklyRevisions*7*24*time.Hour && !weeks[weekKey] {
+			weeks[weekKey] = true
+			keep[id] = true
+		}
+	}
+	for _, id := range ids {
+		if !keep[id] {
+			os.Remove(filepath.Join(revisionDir(name), id+".sav"))
+		}
+	}
+}
+
+func loadSaveRevisions(name string) []SaveRevision {
+	currentMD5 := ""
+	if data, err := os.ReadFile(filepath.Join(savesDir, name)); err == nil {
+		currentMD5 = md5Hex(data)
+	}
+
+	var revs []SaveRevision
+	for _, id := range listRevisionIDs(name) {
+		data, err := os.ReadFile(filepath.Join(revisionDir(name), id+".sav"))
+		if err != nil {
+			continue
+		}
+		rev := SaveRevision{ID: id, Time: parseRevisionTime(id).Unix(), Size: int64(len(data)), MD5: md5Hex(data)}
+		rev.Current = rev.MD5 == currentMD5
+		revs = append(revs, rev)
+	}
+	for i := range revs {
+		if i+1 < len(revs) {
+			revs[i].SizeDelta = revs[i].Size - revs[i+1].Size
+		} else {
+			revs[i].SizeDelta = revs[i].Size
+		}
+	}
+	if revs == nil {
+		revs = []SaveRevision{}
+	}
+	return revs
+}
+
+// 리비전 ID 는 revisionTimeFormat 형식만 허용 (경로 조작 방지)
+func validRevisionID(id string) bool {
+	return id != "" && !parseRevisionTime(id).IsZero() && filepath.Base(id) == id
+}
+
+// GET  /api/save/revisions?name=
+// GET  /api/save/revision?name=&rev=
+// GET  /api/save/diff?name=&a=&b=
+// POST /api/save/restore?name=&rev=
+// GET  /api/save/conflicts?name=
+// GET  /api/save/conflict?name=&id=
+// POST /api/save/resolve?name=&id=&keep=mine|server
+func handleSaveHistory(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		http.Error(w, "Missing name", 400)
+		return
+	}
+	safeName := filepath.Base(name)
+
+	switch strings.TrimPrefix(r.URL.Path, "/api/save/") {
+	case "revisions":
+		if r.Method != "GET" {
+			http.Error(w, "Method not allowed", 405)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(loadSaveRevisions(safeName))
+
+	case "revision":
+		if r.Method != "GET" {
+			http.Error(w, "Method not allowed", 405)
+			return
+		}
+		rev := r.URL.Query().Get("rev")
+		if !validRevisionID(rev) {
+			http.Error(w, "Invalid rev", 400)
+			return
+		}
+		path := filepath.Join(revisionDir(safeName), rev+".sav")
+		if _, err := os.Stat(path); err != nil {
+			http.Error(w, "Not found", 404)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.%s\"", safeName, rev))
+		http.ServeFile(w, r, path)
+
+	case "diff":
+		if r.Method != "GET" {
+			http.Error(w, "Method not allowed", 405)
+			return
+		}
+		handleSaveDiff(w, r, safeName)
+
+	case "restore":
+		if r.Method != "POST" {
+			http.Error(w, "Method not allowed", 405)
+			return
+		}
+		rev := r.URL.Query().Get("rev")
+		if !validRevisionID(rev) {
+			http.Error(w, "Invalid rev", 400)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join(revisionDir(safeName), rev+".sav"))
+		if err != nil {
+			http.Error(w, "Not found", 404)
+			return
+		}
+		// 복원도 새 리비전으로 기록되므로 되돌릴 수 있음
+		savesMu.Lock()
+		_, err = storeSaveRevision(safeName, data)
+		savesMu.Unlock()
+		if err != nil {
+			http.Error(w, "Restore failed", 500)
+			return
+		}
+		w.WriteHeader(200)
+
+	case "conflicts":
+		if r.Method != "GET" {
+			http.Error(w, "Method not allowed", 405)
+			return
+		}
+		handleSaveConflictList(w, safeName)
+
+	case "conflict":
+		if r.Method != "GET" {
+			http.Error(w, "Method not allowed", 405)
+			return
+		}
+		id := r.URL.Query().Get("id")
+		if !validRevisionID(id) {
+			http.Error(w, "Invalid id", 400)
+			return
+		}
+		path := filepath.Join(saveConflictsDir, safeName, id+".sav")
+		if _, err := os.Stat(path); err != nil {
+			http.Error(w, "Not found", 404)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		http.ServeFile(w, r, path)
+
+	case "resolve":
+		if r.Method != "POST" {
+			http.Error(w, "Method not allowed", 405)
+			return
+		}
+		handleSaveResolve(w, r, safeName)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func handleSaveConflictList(w http.ResponseWriter, name string) {
+	type conflict struct {
+		ID   string `json:"id"`
+		Time int64  `json:"time"`
+		Size int64  `json:"size"`
+		MD5  string `json:"md5"`
+	}
+	list := []conflict{}
+	for _, id := range listConflictIDs(name) {
+		data, err := os.ReadFile(filepath.Join(saveConflictsDir, name, id+".sav"))
+		if err != nil {
+			continue
+		}
+		list = append(list, conflict{ID: id, Time: parseRevisionTime(id).Unix(), Size: int64(len(data)), MD5: md5Hex(data)})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"current":   currentSaveETag(name),
+		"conflicts": list,
+	})
+}
+
+// keep=mine: 충돌본을 현재 세이브로, keep=server: 현재 세이브 유지
+// 어느 쪽이든 밀려난 버전은 리비전 기록에 남는다
+func handleSaveResolve(w http.ResponseWriter, r *http.Request, name string) {
+	id := r.URL.Query().Get("id")
+	keep := r.URL.Query().Get("keep")
+	if !validRevisionID(id) || (keep != "mine" && keep != "server") {
+		http.Error(w, "Invalid params", 400)
+		return
+	}
+	conflictPath := filepath.Join(saveConflictsDir, name, id+".sav")
+
+	savesMu.Lock()
+	defer savesMu.Unlock()
+	data, err := os.ReadFile(conflictPath)
+	if err != nil {
+		http.Error(w, "Not found", 404)
+		return
+	}
+
+	if keep == "mine" {
+		_, err = storeSaveRevision(name, data)
+	} else {
+		err = writeFileAtomic(filepath.Join(revisionDir(name), id+".sav"), data, false)
+	}
+	if err != nil {
+		http.Error(w, "Resolve failed", 500)
+		return
+	}
+	os.Remove(conflictPath)
+	if keep == "server" {
+		pruneSaveRevisions(name)
+	}
+	noteSaveUsage(name)
+	w.Header().Set("ETag", fmt.Sprintf(`"%s"`, currentSaveETag(name)))
+	w.WriteHeader(200)
+}
+
+// 두 리비전의 크기/시각 차이와 달라진 바이트 수 (a, b 중 하나가 비면 현재 세이브와 비교)
+func handleSaveDiff(w http.ResponseWriter, r *http.Request, name string) {
+	read := func(rev string) ([]byte, int64, error) {
+		if rev == "" || rev == "current" {
+			path := filepath.Join(savesDir, name)
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil, 0, err
+			}
+			data, err := os.ReadFile(path)
+			return data, info.ModTime().Unix(), err
+		}
+		if !validRevisionID(rev) {
+			return nil, 0, fmt.Errorf("invalid rev")
+		}
+		data, err := os.ReadFile(filepath.Join(revisionDir(name), rev+".sav"))
+		return data, parseRevisionTime(rev).Unix(), err
+	}
+
+	a, b := r.URL.Query().Get("a"), r.URL.Query().Get("b")
+	dataA, timeA, errA := read(a)
+	dataB, timeB, errB := read(b)
+	if errA != nil || errB != nil {
+		http.Error(w, "Not found", 404)
+		return
+	}
+
+	changed := 0
+	for i := 0; i < len(dataA) || i < len(dataB); i++ {
+		if i >= len(dataA) || i >= len(dataB) || dataA[i] != dataB[i] {
+			changed++
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"a":            map[string]interface{}{"rev": a, "time": timeA, "size": len(dataA), "md5": md5Hex(dataA)},
+		"b":            map[string]interface{}{"rev": b, "time": timeB, "size": len(dataB), "md5": md5Hex(dataB)},
+		"sizeDelta":    len(dataB) - len(dataA),
+		"timeDelta":    timeB - timeA,
+		"same":         bytes.Equal(dataA, dataB),
+		"changedBytes": changed,
+	})
+}
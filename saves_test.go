@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func uploadSave(t *testing.T, name string, data []byte, ifMatch string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest("POST", "/api/save?name="+name, bytes.NewReader(data))
+	if ifMatch != "" {
+		r.Header.Set("If-Match", ifMatch)
+	}
+	w := httptest.NewRecorder()
+	handleSaveUpload(w, r)
+	return w
+}
+
+func currentSave(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(savesDir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestUploadOverUntrackedSaveKeepsItAsRevision(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeTestRom(t, "snes", "Game.sfc", "x")
+	const name = "snes-Game.sfc.sav"
+	old := []byte("40 hours of SRAM")
+	if err := os.MkdirAll(savesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// 리비전 기능 이전부터 있던 세이브 (기록 없음)
+	if err := os.WriteFile(filepath.Join(savesDir, name), old, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := uploadSave(t, name, []byte("fresh start"), ""); w.Code != 200 {
+		t.Fatalf("upload: status %d (%s)", w.Code, w.Body)
+	}
+	revs := loadSaveRevisions(name)
+	if len(revs) != 2 {
+		t.Fatalf("revisions = %+v, want the old save and the upload", revs)
+	}
+	oldRev := revs[1]
+	if oldRev.MD5 != md5Hex(old) || oldRev.Current {
+		t.Fatalf("oldest revision = %+v, want the pre-existing save", oldRev)
+	}
+
+	w := httptest.NewRecorder()
+	handleSaveHistory(w, httptest.NewRequest("POST", "/api/save/restore?name="+name+"&rev="+oldRev.ID, nil))
+	if w.Code != 200 {
+		t.Fatalf("restore: status %d (%s)", w.Code, w.Body)
+	}
+	if got := currentSave(t, name); !bytes.Equal(got, old) {
+		t.Errorf("after restore current save = %q, want %q", got, old)
+	}
+	// 복원으로 밀려난 업로드도 기록에 남아 있어야 함
+	found := false
+	for _, rev := range loadSaveRevisions(name) {
+		if rev.MD5 == md5Hex([]byte("fresh start")) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("upload replaced by restore is missing from history")
+	}
+}
+
+func TestTrackedSaveIsNotArchivedTwice(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeTestRom(t, "snes", "Game.sfc", "x")
+	const name = "snes-Game.sfc.sav"
+
+	for i, data := range []string{"one", "two", "three"} {
+		if w := uploadSave(t, name, []byte(data), ""); w.Code != 200 {
+			t.Fatalf("upload %d: status %d (%s)", i, w.Code, w.Body)
+		}
+	}
+	if revs := loadSaveRevisions(name); len(revs) != 3 {
+		t.Errorf("revisions = %+v, want exactly one per upload", revs)
+	}
+}
+
+func TestSaveIfMatchConflicts(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeTestRom(t, "snes", "Game.sfc", "x")
+	const name = "snes-Game.sfc.sav"
+	etag := func(data string) string { return `"` + md5Hex([]byte(data)) + `"` }
+
+	steps := []struct {
+		name    string
+		data    string
+		ifMatch string
+		status  int
+		current string
+	}{
+		{"first upload", "v1", "", 200, "v1"},
+		{"based on current", "v2", etag("v1"), 200, "v2"},
+		{"stale base", "v3", etag("v1"), 409, "v2"},
+		{"stale base, same content", "v2", etag("v1"), 200, "v2"},
+		{"wildcard", "v4", "*", 200, "v4"},
+	}
+	for _, st := range steps {
+		w := uploadSave(t, name, []byte(st.data), st.ifMatch)
+		if w.Code != st.status {
+			t.Fatalf("%s: status %d, want %d (%s)", st.name, w.Code, st.status, w.Body)
+		}
+		if got := string(currentSave(t, name)); got != st.current {
+			t.Errorf("%s: current save = %q, want %q", st.name, got, st.current)
+		}
+		if w.Header().Get("ETag") != etag(st.current) {
+			t.Errorf("%s: ETag = %s, want %s", st.name, w.Header().Get("ETag"), etag(st.current))
+		}
+	}
+
+	ids := listConflictIDs(name)
+	if len(ids) != 1 {
+		t.Fatalf("conflicts = %q, want 1", ids)
+	}
+	w := httptest.NewRecorder()
+	handleSaveHistory(w, httptest.NewRequest("POST", "/api/save/resolve?name="+name+"&id="+ids[0]+"&keep=mine", nil))
+	if w.Code != 200 {
+		t.Fatalf("resolve: status %d (%s)", w.Code, w.Body)
+	}
+	if got := string(currentSave(t, name)); got != "v3" {
+		t.Errorf("after keep=mine current save = %q, want v3", got)
+	}
+	if ids := listConflictIDs(name); len(ids) != 0 {
+		t.Errorf("conflict still stored after resolve: %q", ids)
+	}
+	found := false
+	for _, rev := range loadSaveRevisions(name) {
+		found = found || rev.MD5 == md5Hex([]byte("v4"))
+	}
+	if !found {
+		t.Errorf("server copy replaced by keep=mine is missing from history")
+	}
+}
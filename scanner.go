@@ -0,0 +1,384 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// [스캐너] 시스템 폴더를 하위 폴더까지 훑어 게임 목록을 만든다
+//   - 확장자는 시스템별로 다르게 허용 (data/extensions.json 으로 바꿀 수 있음)
+//   - .cue 가 가리키는 트랙(.bin 등)과 .m3u 가 가리키는 디스크는 따로 보여주지 않음
+//   - 하위 폴더의 게임은 시스템 폴더 기준 상대 경로로 구분한다. 롬 이름(세이브/상태 저장 키)은 파일 이름 그대로이며,
+//     같은 이름이 여러 폴더에 있으면 얕은(먼저 찾은) 쪽이 파일 이름을, 나머지는 "<폴더> - <파일 이름>" 을 쓴다
+const extensionsFile = "./data/extensions.json"
+
+var extensionsStore = &jsonStore{path: extensionsFile}
+
+// 기존 전역 목록 (알 수 없는 시스템 폴더에 적용)
+var defaultRomExts = []string{".zip", ".7z", ".gba", ".nds", ".iso", ".bin", ".chd", ".sfc", ".smc", ".cue", ".m3u"}
+
+var reCueFile = regexp.MustCompile(`(?i)^\s*FILE\s+(?:"([^"]+)"|(\S+))`)
+
+// 스캔 한 번에 쓰는 설정 (시스템 폴더마다 파일을 다시 읽지 않도록 스캔 시작 시 한 번만 읽음)
+type scanSettings struct {
+	config        Config              // index.html 의 coreMap
+	extensions    map[string][]string // data/extensions.json
+	autoPlaylists bool                // 여러 장짜리 게임의 .m3u 를 롬 폴더에 만듦 (RETRO_AUTO_PLAYLIST)
+}
+
+func loadScanSettings() scanSettings {
+	settings := scanSettings{config: loadConfigFromHTML(), extensions: make(map[string][]string), autoPlaylists: autoPlaylistEnabled()}
+	if _, err := extensionsStore.Load(&settings.extensions); err != nil {
+		logger("library").Warn("확장자 설정을 읽지 못해 기본값 사용", "err", err)
+	}
+	return settings
+}
+
+// 시스템 폴더 이름 → 허용 확장자 (data/extensions.json → 코어 목록(cores.go) → "default" → 기존 전역 목록)
+// data/extensions.json: {"default": [...], "psx": [...]}
+func romExtensions(settings scanSettings, sys string) map[string]bool {
+	overrides := settings.extensions
+	list, ok := overrides[sys]
+	if !ok {
+		info, known := lookupSystem(settings.config, sys)
+		list, ok = info.Extensions, known && len(info.Extensions) > 0
+	}
+	if !ok {
+		list, ok = overrides["default"]
+	}
+	if !ok {
+		list = defaultRomExts
+	}
+	exts := make(map[string]bool)
+	for _, e := range list {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if e != "" && !strings.HasPrefix(e, ".") {
+			e = "." + e
+		}
+		exts[e] = true
+	}
+	return exts
+}
+
+// 폴더 밖을 가리키지 않는 상대 경로만 허용 (슬래시 구분)
+func cleanRelPath(p string) (string, bool) {
+	clean := path.Clean(strings.ReplaceAll(p, `\`, "/"))
+	if clean == "." || path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", false
+	}
+	return clean, true
+}
+
+// .cue 의 FILE 항목 (cue 파일이 있는 폴더 기준 상대 경로)
+func readCueTracks(p string) []string {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var tracks []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		m := reCueFile.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		name := m[1]
+		if name == "" {
+			name = m[2]
+		}
+		if clean, ok := cleanRelPath(name); ok {
+			tracks = append(tracks, clean)
+		}
+	}
+	return tracks
+}
+
+// 게임 파일과 함께 있어야 하는 파일 (.m3u 의 디스크와 그 디스크가 .cue 면 트랙까지, .cue 의 트랙)
+// 게임 파일이 있는 폴더 기준 상대 경로
+func romCompanions(p string) []string {
+	var files []string
+	seen := make(map[string]bool)
+	add := func(rel string) {
+		if !seen[rel] {
+			seen[rel] = true
+			files = append(files, rel)
+		}
+	}
+	switch strings.ToLower(filepath.Ext(p)) {
+	case ".m3u":
+		// 가상 재생 목록도 readPlaylist 가 디스크 목록을 돌려줌
+		return playlistCompanions(filepath.Dir(p), readPlaylist(p))
+	case ".cue":
+		for _, t := range readCueTracks(p) {
+			add(t)
+		}
+	}
+	return files
+}
+
+// 재생 목록의 디스크와 그 디스크가 .cue 면 트랙까지 (dir 은 재생 목록이 있는 폴더)
+func playlistCompanions(dir string, discs []string) []string {
+	var files []string
+	seen := make(map[string]bool)
+	add := func(rel string) {
+		if !seen[rel] {
+			seen[rel] = true
+			files = append(files, rel)
+		}
+	}
+	for _, disc := range discs {
+		add(disc)
+		if strings.EqualFold(path.Ext(disc), ".cue") {
+			for _, t := range readCueTracks(filepath.Join(dir, filepath.FromSlash(disc))) {
+				if rel, ok := cleanRelPath(path.Join(path.Dir(disc), t)); ok {
+					add(rel)
+				}
+			}
+		}
+	}
+	return files
+}
+
+// 하위 폴더에 있는 롬의 위치 (시스템 → 롬 이름 → 시스템 폴더 기준 상대 경로)
+var romLocations struct {
+	sync.Mutex
+	bySys     map[string]map[string]string
+	playlists map[string]map[string][]string // 시스템 → 가상 재생 목록 경로 → 디스크 (재생 목록 폴더 기준 상대 경로)
+	treeTime  map[string]time.Time           // 시스템 → 스캔할 때의 롬 폴더 수정 시각 (바뀌면 다시 스캔)
+}
+
+// 시스템 폴더 하나를 하위 폴더까지 스캔
+func scanSystemDir(baseDir, sys string, settings scanSettings) []RomInfo {
+	sysDir := filepath.Join(baseDir, sys)
+	exts := romExtensions(settings, sys)
+	treeTime := romsTreeModTime() // 스캔 도중 바뀐 것은 다음 확인에서 다시 스캔되도록 먼저 기록
+
+	byDir := make(map[string][]RomInfo) // 시스템 폴더 기준 상대 경로(슬래시) → 롬
+	var dirs []string
+	filepath.WalkDir(sysDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			logger("library").Warn("롬 폴더 읽기 실패", "path", p, "err", err)
+			return nil
+		}
+		if d.IsDir() {
+			if p != sysDir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			rel, _ := filepath.Rel(sysDir, p)
+			dirs = append(dirs, filepath.ToSlash(rel))
+			return nil
+		}
+		if !exts[strings.ToLower(filepath.Ext(d.Name()))] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(sysDir, filepath.Dir(p))
+		rel = filepath.ToSlash(rel)
+		byDir[rel] = append(byDir[rel], RomInfo{Name: d.Name(), Size: info.Size(), ModTime: info.ModTime().Unix()})
+		return nil
+	})
+	// 얕은 폴더부터 (같은 이름이면 위쪽 폴더가 우선)
+	sort.SliceStable(dirs, func(i, j int) bool { return dirDepth(dirs[i]) < dirDepth(dirs[j]) })
+
+	// 폴더별로 디스크를 묶은 뒤, 재생 목록/cue 가 가리키는 파일을 숨김
+	hidden := make(map[string]bool)
+	virtualDirs := make(map[string]string) // 가상 재생 목록 경로 → 폴더 (상대 경로)
+	playlists := make(map[string][]string)
+	for _, dir := range dirs {
+		if len(byDir[dir]) == 0 {
+			continue
+		}
+		abs := filepath.Join(sysDir, filepath.FromSlash(dir))
+		var virtual map[string][]string
+		byDir[dir], virtual = groupMultiDisc(abs, byDir[dir], settings.autoPlaylists)
+		for name, discs := range virtual {
+			p := filepath.Clean(filepath.Join(abs, name))
+			playlists[p] = discs
+			virtualDirs[p] = dir
+		}
+		for i, r := range byDir[dir] {
+			p := filepath.Clean(filepath.Join(abs, r.Name))
+			var companions []string
+			if discs, ok := playlists[p]; ok {
+				companions = playlistCompanions(abs, discs)
+			} else {
+				companions = romCompanions(p)
+			}
+			for _, c := range companions {
+				hidden[path.Join(dir, c)] = true
+			}
+			if strings.EqualFold(filepath.Ext(r.Name), ".cue") {
+				for _, c := range companions {
+					byDir[dir][i].Size += fileSize(filepath.Join(abs, filepath.FromSlash(c)))
+				}
+			}
+		}
+	}
+
+	var list []RomInfo
+	seen := make(map[string]bool)
+	locations := make(map[string]string)
+	for _, dir := range dirs {
+		for _, r := range byDir[dir] {
+			rel := path.Join(dir, r.Name)
+			if hidden[rel] {
+				continue
+			}
+			if seen[r.Name] {
+				// 얕은 폴더에 같은 이름이 있으면 폴더 경로를 붙여 구분 ("USA/Game.sfc" → "USA - Game.sfc")
+				alias := strings.ReplaceAll(dir, "/", " - ") + " - " + r.Name
+				if seen[alias] {
+					logger("library").Warn("같은 이름의 롬이 있어 건너뜀", "sys", sys, "path", rel)
+					continue
+				}
+				r.Name = alias
+			}
+			seen[r.Name] = true
+			// 가상 재생 목록은 파일이 없으므로 시스템 폴더 바로 아래여도 위치를 기록
+			_, virtual := virtualDirs[filepath.Clean(filepath.Join(sysDir, filepath.FromSlash(rel)))]
+			if dir != "." || virtual {
+				locations[r.Name] = rel
+			}
+			list = append(list, r)
+		}
+	}
+
+	romLocations.Lock()
+	if romLocations.bySys == nil {
+		romLocations.bySys = make(map[string]map[string]string)
+	}
+	romLocations.bySys[sys] = locations
+	if romLocations.playlists == nil {
+		romLocations.playlists = make(map[string]map[string][]string)
+	}
+	romLocations.playlists[sys] = playlists
+	if romLocations.treeTime == nil {
+		romLocations.treeTime = make(map[string]time.Time)
+	}
+	romLocations.treeTime[sys] = treeTime
+	romLocations.Unlock()
+	return list
+}
+
+func dirDepth(rel string) int {
+	if rel == "." {
+		return 0
+	}
+	return strings.Count(rel, "/") + 1
+}
+
+// 롬 파일을 옮기거나 지운 뒤 다음 조회에서 다시 스캔하도록 함
+func forgetRomLocations(systems ...string) {
+	romLocations.Lock()
+	defer romLocations.Unlock()
+	for _, sys := range systems {
+		delete(romLocations.bySys, sys)
+		delete(romLocations.playlists, sys)
+		delete(romLocations.treeTime, sys)
+	}
+}
+
+// 롬 이름 → 실제 파일 경로 (시스템 폴더 바로 아래에 없으면 하위 폴더 위치를 찾음)
+func resolveRomPath(sys, rom string) (string, bool) {
+	sys, rom = filepath.Base(sys), filepath.Base(rom)
+	top := filepath.Join(romsDir, sys, rom)
+	if info, err := os.Stat(top); err == nil && !info.IsDir() {
+		return top, true
+	}
+	// 롬 폴더(하위 폴더 포함)가 바뀌었으면 스캔 결과를 버리고 다시 스캔
+	treeTime := romsTreeModTime()
+	lookup := func() (string, bool) {
+		romLocations.Lock()
+		defer romLocations.Unlock()
+		locations, scanned := romLocations.bySys[sys]
+		return locations[rom], scanned && romLocations.treeTime[sys].Equal(treeTime)
+	}
+	rel, scanned := lookup()
+	if !scanned {
+		// 아직 한 번도 스캔하지 않은 시스템 (새로 넣은 하위 폴더 파일은 라이브러리 색인이 갱신될 때 반영)
+		if info, err := os.Stat(filepath.Join(romsDir, sys)); err != nil || !info.IsDir() {
+			return top, false
+		}
+		scanSystemDir(romsDir, sys, loadScanSettings())
+		rel, _ = lookup()
+	}
+	if rel == "" {
+		return top, false
+	}
+	p := filepath.Join(romsDir, sys, filepath.FromSlash(rel))
+	if info, err := os.Stat(p); err == nil && !info.IsDir() {
+		return p, true
+	}
+	if _, ok := virtualPlaylist(p); ok {
+		return p, true
+	}
+	return top, false
+}
+
+// 롬 파일을 가리키는 URL 경로 (/data/roms/<sys>/<하위 폴더>/<파일>)
+func romURL(sys string, rel string) string {
+	parts := strings.Split(path.Join(sys, rel), "/")
+	for i := range parts {
+		parts[i] = url.PathEscape(parts[i])
+	}
+	return "/data/roms/" + strings.Join(parts, "/")
+}
+
+// 하위 폴더에 있는 롬도 /data/roms/<sys>/<rom> 주소로 받을 수 있도록 경로를 바꿔줌
+func rewriteNestedRomPath(r *http.Request) {
+	rest, ok := strings.CutPrefix(r.URL.Path, "/data/roms/")
+	if !ok {
+		return
+	}
+	sys, rom, ok := strings.Cut(rest, "/")
+	if !ok || strings.Contains(rom, "/") {
+		return
+	}
+	if _, err := os.Stat(filepath.Join(romsDir, filepath.Base(sys), filepath.Base(rom))); err == nil {
+		return
+	}
+	if p, ok := resolveRomPath(sys, rom); ok {
+		rel, err := filepath.Rel(filepath.Join(romsDir, filepath.Base(sys)), p)
+		if err == nil {
+			r.URL.Path = "/data/roms/" + filepath.Base(sys) + "/" + filepath.ToSlash(rel)
+			r.URL.RawPath = ""
+		}
+	}
+}
+
+// GET /api/rom/files?sys=&rom= : 게임과 함께 내려받아야 하는 파일 (런처의 EJS_externalFiles)
+// 응답: {"files": {"<게임 파일 기준 상대 경로>": "<URL>"}}
+func handleRomFiles(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	sys := filepath.Base(r.URL.Query().Get("sys"))
+	p, ok := resolveRomPath(sys, r.URL.Query().Get("rom"))
+	if !ok {
+		http.Error(w, "Unknown rom", 404)
+		return
+	}
+	romDir, _ := filepath.Rel(filepath.Join(romsDir, sys), filepath.Dir(p))
+	files := make(map[string]string)
+	for _, rel := range romCompanions(p) {
+		files[rel] = romURL(sys, path.Join(filepath.ToSlash(romDir), rel))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"files": files})
+}
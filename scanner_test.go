@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCleanRelPath(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"track01.bin", "track01.bin", true},
+		{"sub/track01.bin", "sub/track01.bin", true},
+		{`sub\track01.bin`, "sub/track01.bin", true},
+		{"./sub//../track01.bin", "track01.bin", true},
+		{"../track01.bin", "", false},
+		{`..\track01.bin`, "", false},
+		{"sub/../../track01.bin", "", false},
+		{"/etc/passwd", "", false},
+		{"..", "", false},
+		{".", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := cleanRelPath(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("cleanRelPath(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestReadCueTracks(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"quoted", "FILE \"Game (Track 1).bin\" BINARY\n  TRACK 01 MODE2/2352\n", []string{"Game (Track 1).bin"}},
+		{"unquoted", "FILE track02.bin BINARY\r\n", []string{"track02.bin"}},
+		{"multiple", "FILE \"a.bin\" BINARY\nTRACK 01 MODE1/2352\nfile \"b.wav\" WAVE\n", []string{"a.bin", "b.wav"}},
+		{"subfolder", "FILE \"tracks\\a.bin\" BINARY\n", []string{"tracks/a.bin"}},
+		{"outside folder", "FILE \"../a.bin\" BINARY\nFILE \"/a.bin\" BINARY\n", nil},
+		{"no files", "REM COMMENT\nTRACK 01 AUDIO\n", nil},
+	}
+	dir := t.TempDir()
+	for _, tt := range tests {
+		p := filepath.Join(dir, tt.name+".cue")
+		if err := os.WriteFile(p, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got := readCueTracks(p); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: readCueTracks = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestScanKeepsSameNameInSubfolders(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeTestRom(t, "snes", "Game.sfc", "top")
+	writeTestRom(t, "snes", "USA/Game.sfc", "usa")
+	writeTestRom(t, "snes", "Japan/Rev 1/Game.sfc", "japan")
+
+	var names []string
+	for _, r := range scanRomLibrary(romsDir)["snes"] {
+		names = append(names, r.Name)
+	}
+	want := []string{"Game.sfc", "USA - Game.sfc", "Japan - Rev 1 - Game.sfc"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("snes library = %q, want %q", names, want)
+	}
+	for rom, content := range map[string]string{"Game.sfc": "top", "USA - Game.sfc": "usa", "Japan - Rev 1 - Game.sfc": "japan"} {
+		p, ok := resolveRomPath("snes", rom)
+		data, err := os.ReadFile(p)
+		if !ok || err != nil || string(data) != content {
+			t.Errorf("resolveRomPath(%q) = %q, %v (content %q), want the %q file", rom, p, ok, data, content)
+		}
+	}
+
+	// 휴지통에서 되돌리면 원래 하위 폴더의 원래 파일 이름으로
+	item, err := moveRomToTrash("snes", "USA - Game.sfc", defaultUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := restoreFromTrash(item.ID); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(romsDir, "snes", "USA", "Game.sfc")); err != nil || string(data) != "usa" {
+		t.Errorf("restored file = %q, %v", data, err)
+	}
+}
+
+func TestRomLocationsRescanWhenFolderChanges(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeTestRom(t, "snes", "sub/A.sfc", "a")
+	if !romExists("snes", "A.sfc") {
+		t.Fatal("nested rom not found")
+	}
+
+	// 서버를 거치지 않고 하위 폴더에 새 롬을 넣음 (forgetRomLocations 호출 없음)
+	dir := filepath.Join(romsDir, "snes", "more")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "B.sfc"), []byte("b"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(dir, future, future); err != nil {
+		t.Fatal(err)
+	}
+	// 폴더 시각 재확인 주기가 지난 것으로 취급
+	romsTree.Lock()
+	romsTree.checked = time.Time{}
+	romsTree.Unlock()
+
+	if !romExists("snes", "B.sfc") {
+		t.Errorf("rom added to a new subfolder was not found after the folder changed")
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	screenshotDir = "./data/screenshots"
+	thumbDir      = "./data/thumbs"
+	boxartDir     = "./data/boxart"
+	thumbWidth    = 256
+	// 작은 PNG 가 큰 크기를 선언해 디코딩 메모리를 키우지 못하도록 크기를 먼저 확인
+	maxScreenshotDim = 4096
+)
+
+// 박스아트로 인정하는 확장자 (우선순위 순)
+var boxartExts = []string{".png", ".jpg", ".jpeg", ".webp"}
+
+// [스크린샷] 프론트엔드가 캡처한 캔버스 이미지를 저장하고 카드용 썸네일을 생성
+func handleScreenshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	sys := r.URL.Query().Get("sys")
+	rom := r.URL.Query().Get("rom")
+	if sys == "" || rom == "" {
+		http.Error(w, "Missing params", 400)
+		return
+	}
+	safeSys := filepath.Base(sys)
+	safeRom := filepath.Base(rom)
+	if !romExists(safeSys, safeRom) {
+		http.Error(w, "Unknown rom", 404)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxScreenshotBytes))
+	if err != nil {
+		writeUploadError(w, err, 400)
+		return
+	}
+	if len(data) == 0 {
+		http.Error(w, "Empty body", 400)
+		return
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		http.Error(w, "Unsupported image", 415)
+		return
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > maxScreenshotDim || cfg.Height > maxScreenshotDim {
+		http.Error(w, fmt.Sprintf("Image too large (max %dx%d)", maxScreenshotDim, maxScreenshotDim), http.StatusRequestEntityTooLarge)
+		return
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		http.Error(w, "Unsupported image", 415)
+		return
+	}
+
+	// 원본은 포맷 그대로 보관 (이전 포맷의 파일은 정리)
+	origDir := filepath.Join(screenshotDir, safeSys)
+	os.MkdirAll(origDir, 0755)
+	for _, ext := range []string{".png", ".jpeg"} {
+		os.Remove(filepath.Join(origDir, safeRom+ext))
+	}
+	if err := os.WriteFile(filepath.Join(origDir, safeRom+"."+format), data, 0644); err != nil {
+		http.Error(w, "Write failed", 500)
+		return
+	}
+
+	if err := writeThumbnail(img, thumbPath(safeSys, safeRom)); err != nil {
+		logger("screenshot").Error("썸네일 생성 실패", "sys", safeSys, "rom", safeRom, "err", err)
+		http.Error(w, "Thumbnail failed", 500)
+		return
+	}
+
+	// 카드 이미지가 바뀌었으므로 검색 인덱스와 SSR 캐시 무효화
+	invalidateLibraryIndex()
+	invalidateIndexCache()
+	w.WriteHeader(200)
+}
+
+func thumbPath(sys, rom string) string {
+	return filepath.Join(thumbDir, sys, rom+".jpg")
+}
+
+// 가로 thumbWidth 기준으로 박스 필터 축소 후 JPEG 저장
+func writeThumbnail(src image.Image, dest string) error {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if sw == 0 || sh == 0 {
+		return fmt.Errorf("empty image")
+	}
+	dw := thumbWidth
+	if sw < dw {
+		dw = sw
+	}
+	dh := sh * dw / sw
+	if dh < 1 {
+		dh = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0 := b.Min.Y + y*sh/dh
+		y1 := b.Min.Y + (y+1)*sh/dh
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < dw; x++ {
+			x0 := b.Min.X + x*sw/dw
+			x1 := b.Min.X + (x+1)*sw/dw
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var rs, gs, bs, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, _ := src.At(sx, sy).RGBA()
+					rs += cr >> 8
+					gs += cg >> 8
+					bs += cb >> 8
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA{uint8(rs / n), uint8(gs / n), uint8(bs / n), 255})
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	tmp := dest + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := jpeg.Encode(out, dst, &jpeg.Options{Quality: 80}); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	out.Close()
+	return os.Rename(tmp, dest)
+}
+
+// 카드에 쓸 이미지 URL: 박스아트가 있으면 우선, 없으면 스크린샷 썸네일, 둘 다 없으면 ""
+func cardImageURL(sys, rom string) string {
+	romNoExt := strings.TrimSuffix(rom, filepath.Ext(rom))
+	for _, ext := range boxartExts {
+		p := filepath.Join(boxartDir, sys, romNoExt+ext)
+		if info, err := os.Stat(p); err == nil {
+			return imageURL("/data/boxart", sys, romNoExt+ext, info.ModTime().Unix())
+		}
+	}
+	if info, err := os.Stat(thumbPath(sys, rom)); err == nil {
+		return imageURL("/data/thumbs", sys, rom+".jpg", info.ModTime().Unix())
+	}
+	return ""
+}
+
+func imageURL(base, sys, file string, version int64) string {
+	return fmt.Sprintf("%s/%s/%s?v=%d", base, url.PathEscape(sys), url.PathEscape(file), version)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// IHDR 의 가로/세로만 바꾼 PNG (픽셀 데이터는 작은 그대로)
+func pngWithDeclaredSize(t *testing.T, w, h uint32) []byte {
+	data := testPNG(t, 1, 1)
+	ihdr := data[8+8 : 8+8+13] // 시그니처, 길이+타입 다음
+	binary.BigEndian.PutUint32(ihdr[0:4], w)
+	binary.BigEndian.PutUint32(ihdr[4:8], h)
+	binary.BigEndian.PutUint32(data[8+8+13:], crc32.ChecksumIEEE(data[8+4:8+8+13]))
+	return data
+}
+
+func TestHandleScreenshot(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeTestRom(t, "snes", "Game.sfc", "rom data")
+
+	tests := []struct {
+		name   string
+		body   []byte
+		status int
+	}{
+		{"normal capture", testPNG(t, 320, 240), 200},
+		{"declared too wide", pngWithDeclaredSize(t, 100000, 1), 413},
+		{"declared too large", pngWithDeclaredSize(t, 60000, 60000), 413},
+		{"not an image", []byte("hello"), 415},
+		{"empty", nil, 400},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", "/api/screenshot?sys=snes&rom=Game.sfc", bytes.NewReader(tt.body))
+		w := httptest.NewRecorder()
+		handleScreenshot(w, r)
+		if w.Code != tt.status {
+			t.Errorf("%s: status %d, want %d (%s)", tt.name, w.Code, tt.status, w.Body)
+		}
+	}
+	if _, err := os.Stat(thumbPath("snes", "Game.sfc")); err != nil {
+		t.Errorf("thumbnail not written: %v", err)
+	}
+}
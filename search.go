@@ -0,0 +1,542 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const metadataFile = "./data/metadata.json"
+
+// [메타데이터] data/metadata.json: { "neogeo/mslug.zip": { "title": "Metal Slug", "genre": "shooter", ... } }
+type RomMeta struct {
+	Title    string `json:"title,omitempty"`
+	Genre    string `json:"genre,omitempty"`
+	Players  int    `json:"players,omitempty"`
+	Year     int    `json:"year,omitempty"`
+	Verified bool   `json:"verified,omitempty"`
+}
+
+// [검색 인덱스] 롬 목록 + 역색인. 입력 파일이 바뀌었을 때만 재생성
+type libraryIndex struct {
+	key       libraryIndexKey
+	entries   []RomEntry
+	summaries []SystemSummary
+	grams     map[string][]int // 제목 n-gram(1~3) → entry 번호
+	bySystem  map[string][]int
+	byGenre   map[string][]int
+	byYear    map[int][]int
+	byPlayers map[int][]int
+	verified  []int
+	withSave  []int
+
+	statsVersion int64   // 반영한 플레이 통계 버전 (playStatsVersion)
+	saveTimes    []int64 // 항목별 세이브 파일 시각 (플레이 기록이 없을 때의 LastPlayed)
+}
+
+type libraryIndexKey struct {
+	RomsTime     time.Time
+	MetaTime     time.Time
+	SavesTime    time.Time
+	BookmarkTime time.Time
+	ConfigTime   time.Time
+	ExtTime      time.Time
+	Generation   int64
+}
+
+var libIndex struct {
+	sync.Mutex
+	idx *libraryIndex
+}
+
+// 무효화 세대 번호. 다른 잠금을 쥔 채로 호출해도 안전하도록 atomic 사용
+var libIndexGeneration atomic.Int64
+
+// [검색 조건] /api/roms 와 SSR(/?q=...) 공용
+type RomQuery struct {
+	Text       string
+	Fuzzy      bool
+	Systems    []string
+	Genres     []string
+	MinPlayers int
+	YearFrom   int
+	YearTo     int
+	Verified   *bool
+	HasSave    *bool
+	Sort       string // name | size | added | recent | played (빈 값이면 시스템/이름 순)
+	Desc       bool
+}
+
+func (q RomQuery) isEmpty() bool {
+	return q.Text == "" && len(q.Systems) == 0 && len(q.Genres) == 0 && q.MinPlayers == 0 &&
+		q.YearFrom == 0 && q.YearTo == 0 && q.Verified == nil && q.HasSave == nil && q.Sort == ""
+}
+
+func modTimeOf(path string) time.Time {
+	if info, err := os.Stat(path); err == nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
+
+// 썸네일 변경, 세이브/롬 제자리 덮어쓰기처럼 폴더 시각으로 감지할 수 없는 변화가 있을 때 호출
+func invalidateLibraryIndex() {
+	libIndexGeneration.Add(1)
+}
+
+// 롬 폴더 전체의 최신 수정 시각. 폴더를 모두 훑어야 하므로 잠시 재사용하고,
+// 무효화 세대가 바뀌면 바로 다시 확인한다 (메인 페이지와 검색 인덱스가 한 요청에서 두 번 훑지 않도록)
+const romsTreeRecheck = 3 * time.Second
+
+var romsTree struct {
+	sync.Mutex
+	checked    time.Time
+	generation int64
+	latest     time.Time
+}
+
+func romsTreeModTime() time.Time {
+	romsTree.Lock()
+	defer romsTree.Unlock()
+	gen := libIndexGeneration.Load()
+	if romsTree.checked.IsZero() || gen != romsTree.generation || time.Since(romsTree.checked) >= romsTreeRecheck {
+		romsTree.latest = getLatestModTime(romsDir)
+		romsTree.checked, romsTree.generation = time.Now(), gen
+	}
+	return romsTree.latest
+}
+
+func getLibraryIndex() *libraryIndex {
+	libIndex.Lock()
+	defer libIndex.Unlock()
+
+	key := libraryIndexKey{
+		RomsTime:     romsTreeModTime(),
+		MetaTime:     modTimeOf(metadataFile),
+		SavesTime:    modTimeOf(savesDir),
+		BookmarkTime: modTimeOf("./data/bookmark.json"),
+		ConfigTime:   modTimeOf("index.html"),
+		ExtTime:      modTimeOf(extensionsFile),
+		Generation:   libIndexGeneration.Load(),
+	}
+	if libIndex.idx != nil && libIndex.idx.key == key {
+		// 플레이 통계만 바뀌었으면 폴더를 다시 훑지 않고 통계 필드만 새로 채움
+		if v := playStatsVersion.Load(); libIndex.idx.statsVersion != v {
+			libIndex.idx = libIndex.idx.withPlayStats(v)
+		}
+		return libIndex.idx
+	}
+	libIndex.idx = buildLibraryIndex(key)
+	return libIndex.idx
+}
+
+// 항목을 복사해 통계 필드만 바꾼 인덱스 (검색 중인 요청이 보는 기존 인덱스는 그대로 둠)
+func (idx *libraryIndex) withPlayStats(version int64) *libraryIndex {
+	next := *idx
+	next.statsVersion = version
+	next.entries = append([]RomEntry(nil), idx.entries...)
+	applyPlayStats(next.entries, idx.saveTimes, playStatsByRom())
+	return &next
+}
+
+func loadRomMetadata() map[string]RomMeta {
+	meta := make(map[string]RomMeta)
+	if data, err := os.ReadFile(metadataFile); err == nil {
+		if err := json.Unmarshal(data, &meta); err != nil {
+			logger("library").Warn("메타데이터 파싱 실패", "path", metadataFile, "err", err)
+		}
+	}
+	return meta
+}
+
+func buildLibraryIndex(key libraryIndexKey) *libraryIndex {
+	statsVersion := playStatsVersion.Load()
+	entries, summaries := collectRomEntries()
+	meta := loadRomMetadata()
+
+	idx := &libraryIndex{
+		key:          key,
+		entries:      entries,
+		summaries:    summaries,
+		grams:        make(map[string][]int),
+		bySystem:     make(map[string][]int),
+		byGenre:      make(map[string][]int),
+		byYear:       make(map[int][]int),
+		byPlayers:    make(map[int][]int),
+		statsVersion: statsVersion,
+		saveTimes:    make([]int64, len(entries)),
+	}
+	for i := range entries {
+		idx.saveTimes[i] = entries[i].LastPlayed
+	}
+	applyPlayStats(idx.entries, idx.saveTimes, playStatsByRom())
+	for i := range idx.entries {
+		e := &idx.entries[i]
+		if m, ok := meta[e.System+"/"+e.Name]; ok {
+			if m.Title != "" {
+				e.Title = m.Title
+			}
+			e.Genre = strings.ToLower(m.Genre)
+			e.Players = m.Players
+			e.Year = m.Year
+			e.Verified = m.Verified
+		}
+
+		for _, g := range titleGrams(e.Title) {
+			idx.grams[g] = append(idx.grams[g], i)
+		}
+		idx.bySystem[e.System] = append(idx.bySystem[e.System], i)
+		if e.Genre != "" {
+			idx.byGenre[e.Genre] = append(idx.byGenre[e.Genre], i)
+		}
+		if e.Year > 0 {
+			idx.byYear[e.Year] = append(idx.byYear[e.Year], i)
+		}
+		if e.Players > 0 {
+			idx.byPlayers[e.Players] = append(idx.byPlayers[e.Players], i)
+		}
+		if e.Verified {
+			idx.verified = append(idx.verified, i)
+		}
+		if e.HasSave {
+			idx.withSave = append(idx.withSave, i)
+		}
+	}
+	return idx
+}
+
+// 제목을 소문자로 정규화한 뒤 길이 1~3 의 n-gram 집합을 만든다 (중복 제거)
+func titleGrams(title string) []string {
+	runes := []rune(strings.ToLower(title))
+	seen := make(map[string]bool)
+	var grams []string
+	for n := 1; n <= 3; n++ {
+		for i := 0; i+n <= len(runes); i++ {
+			g := string(runes[i : i+n])
+			if !seen[g] {
+				seen[g] = true
+				grams = append(grams, g)
+			}
+		}
+	}
+	return grams
+}
+
+// 검색어의 n-gram (검색어가 짧으면 그 길이 그대로)
+func queryGrams(text string) []string {
+	runes := []rune(strings.ToLower(text))
+	n := 3
+	if len(runes) < n {
+		n = len(runes)
+	}
+	seen := make(map[string]bool)
+	var grams []string
+	for i := 0; i+n <= len(runes); i++ {
+		g := string(runes[i : i+n])
+		if !seen[g] {
+			seen[g] = true
+			grams = append(grams, g)
+		}
+	}
+	return grams
+}
+
+// 정렬된 두 목록의 교집합
+func intersectSorted(a, b []int) []int {
+	var out []int
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, a[i])
+			i++
+			j++
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return out
+}
+
+func unionSorted(lists ...[]int) []int {
+	seen := make(map[int]bool)
+	var out []int
+	for _, l := range lists {
+		for _, v := range l {
+			if !seen[v] {
+				seen[v] = true
+				out = append(out, v)
+			}
+		}
+	}
+	sort.Ints(out)
+	return out
+}
+
+// 검색 실행. 후보 집합은 모두 색인에서 가져오며 전체 목록을 훑지 않는다
+func (idx *libraryIndex) search(q RomQuery) []RomEntry {
+	var candidates []int
+	all := true
+	narrow := func(list []int) {
+		if all {
+			candidates = list
+			all = false
+		} else {
+			candidates = intersectSorted(candidates, list)
+		}
+	}
+
+	if len(q.Systems) > 0 {
+		var lists [][]int
+		for _, s := range q.Systems {
+			lists = append(lists, idx.bySystem[s])
+		}
+		narrow(unionSorted(lists...))
+	}
+	if len(q.Genres) > 0 {
+		var lists [][]int
+		for _, g := range q.Genres {
+			lists = append(lists, idx.byGenre[strings.ToLower(g)])
+		}
+		narrow(unionSorted(lists...))
+	}
+	if q.MinPlayers > 0 {
+		var lists [][]int
+		for players, list := range idx.byPlayers {
+			if players >= q.MinPlayers {
+				lists = append(lists, list)
+			}
+		}
+		narrow(unionSorted(lists...))
+	}
+	if q.YearFrom > 0 || q.YearTo > 0 {
+		var lists [][]int
+		for year, list := range idx.byYear {
+			if (q.YearFrom == 0 || year >= q.YearFrom) && (q.YearTo == 0 || year <= q.YearTo) {
+				lists = append(lists, list)
+			}
+		}
+		narrow(unionSorted(lists...))
+	}
+	if q.Verified != nil {
+		narrow(idx.complementIf(idx.verified, *q.Verified))
+	}
+	if q.HasSave != nil {
+		narrow(idx.complementIf(idx.withSave, *q.HasSave))
+	}
+
+	var scores map[int]float64
+	if q.Text != "" {
+		// 유사 검색은 fuzzy=1 일 때만 (부분 문자열 결과가 없다고 조용히 바꾸지 않음)
+		var matched []int
+		if q.Fuzzy {
+			matched, scores = idx.fuzzyMatches(q.Text)
+		} else {
+			matched = idx.substringMatches(q.Text)
+		}
+		narrow(matched)
+	}
+
+	if all {
+		candidates = make([]int, len(idx.entries))
+		for i := range candidates {
+			candidates[i] = i
+		}
+	}
+
+	results := make([]RomEntry, 0, len(candidates))
+	for _, i := range candidates {
+		results = append(results, idx.entries[i])
+	}
+	sortRomEntries(results, candidates, q, scores)
+	return results
+}
+
+// want=false 이면 list 의 여집합
+func (idx *libraryIndex) complementIf(list []int, want bool) []int {
+	if want {
+		return list
+	}
+	in := make(map[int]bool, len(list))
+	for _, v := range list {
+		in[v] = true
+	}
+	var out []int
+	for i := range idx.entries {
+		if !in[i] {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+func (idx *libraryIndex) substringMatches(text string) []int {
+	grams := queryGrams(text)
+	if len(grams) == 0 {
+		return nil
+	}
+	candidates := idx.grams[grams[0]]
+	for _, g := range grams[1:] {
+		candidates = intersectSorted(candidates, idx.grams[g])
+	}
+	// n-gram 이 모두 포함되어도 순서가 다를 수 있으므로 후보만 최종 확인
+	needle := strings.ToLower(text)
+	var out []int
+	for _, i := range candidates {
+		if strings.Contains(strings.ToLower(idx.entries[i].Title), needle) {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// 검색어 n-gram 중 절반 이상을 공유하는 제목을 유사 일치로 본다
+func (idx *libraryIndex) fuzzyMatches(text string) ([]int, map[int]float64) {
+	grams := queryGrams(text)
+	if len(grams) == 0 {
+		return nil, nil
+	}
+	counts := make(map[int]int)
+	for _, g := range grams {
+		for _, i := range idx.grams[g] {
+			counts[i]++
+		}
+	}
+	scores := make(map[int]float64)
+	var out []int
+	for i, c := range counts {
+		score := float64(c) / float64(len(grams))
+		if score >= 0.5 {
+			scores[i] = score
+			out = append(out, i)
+		}
+	}
+	sort.Ints(out)
+	return out, scores
+}
+
+func sortRomEntries(results []RomEntry, ids []int, q RomQuery, scores map[int]float64) {
+	type pair struct {
+		entry RomEntry
+		id    int
+	}
+	pairs := make([]pair, len(results))
+	for i := range results {
+		pairs[i] = pair{results[i], ids[i]}
+	}
+
+	var less func(a, b pair) bool
+	switch q.Sort {
+	case "name":
+		less = func(a, b pair) bool { return strings.ToLower(a.entry.Title) < strings.ToLower(b.entry.Title) }
+	case "size":
+		less = func(a, b pair) bool { return a.entry.Size > b.entry.Size }
+	case "added":
+		less = func(a, b pair) bool { return a.entry.ModTime > b.entry.ModTime }
+	case "recent":
+		less = func(a, b pair) bool { return a.entry.LastPlayed > b.entry.LastPlayed }
+	case "played":
+		less = func(a, b pair) bool {
+			if a.entry.PlayTime != b.entry.PlayTime {
+				return a.entry.PlayTime > b.entry.PlayTime
+			}
+			return a.entry.PlayCount > b.entry.PlayCount
+		}
+	default:
+		if scores != nil {
+			less = func(a, b pair) bool { return scores[a.id] > scores[b.id] }
+		}
+	}
+	if less == nil {
+		return
+	}
+	if q.Desc {
+		base := less
+		less = func(a, b pair) bool { return base(b, a) }
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return less(pairs[i], pairs[j]) })
+	for i := range pairs {
+		results[i] = pairs[i].entry
+	}
+}
+
+func splitList(v string) []string {
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func parseBoolParam(v string) (*bool, error) {
+	if v == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// 쿼리스트링 → RomQuery
+// q, fuzzy, system, genre, players, year(1995 또는 1990-1995), verified, hasSave, sort, order
+func parseRomQuery(r *http.Request) (RomQuery, error) {
+	return parseRomQueryValues(r.URL.Query())
+}
+
+func parseRomQueryValues(v url.Values) (RomQuery, error) {
+	q := RomQuery{
+		Text:    strings.TrimSpace(v.Get("q")),
+		Fuzzy:   v.Get("fuzzy") == "1" || v.Get("fuzzy") == "true",
+		Systems: splitList(v.Get("system")),
+		Genres:  splitList(v.Get("genre")),
+		Sort:    v.Get("sort"),
+		Desc:    v.Get("order") == "desc",
+	}
+	switch q.Sort {
+	case "", "name", "size", "added", "recent", "played":
+	default:
+		return q, fmt.Errorf("Invalid sort")
+	}
+	if p := v.Get("players"); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 1 {
+			return q, fmt.Errorf("Invalid players")
+		}
+		q.MinPlayers = n
+	}
+	if y := v.Get("year"); y != "" {
+		from, to, found := strings.Cut(y, "-")
+		var err error
+		if q.YearFrom, err = strconv.Atoi(from); err != nil {
+			return q, fmt.Errorf("Invalid year")
+		}
+		q.YearTo = q.YearFrom
+		if found {
+			if q.YearTo, err = strconv.Atoi(to); err != nil {
+				return q, fmt.Errorf("Invalid year")
+			}
+		}
+	}
+	var err error
+	if q.Verified, err = parseBoolParam(v.Get("verified")); err != nil {
+		return q, fmt.Errorf("Invalid verified")
+	}
+	if q.HasSave, err = parseBoolParam(v.Get("hasSave")); err != nil {
+		return q, fmt.Errorf("Invalid hasSave")
+	}
+	return q, nil
+}
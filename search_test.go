@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func searchNames(q RomQuery) []string {
+	var names []string
+	for _, e := range getLibraryIndex().search(q) {
+		names = append(names, e.System+"/"+e.Name)
+	}
+	return names
+}
+
+func TestSearchFuzzyIsExplicit(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeTestRom(t, "snes", "Super Mario World.sfc", "x")
+	writeTestRom(t, "nes", "Zelda.nes", "x")
+
+	tests := []struct {
+		name  string
+		query RomQuery
+		want  int
+	}{
+		{"substring", RomQuery{Text: "mario"}, 1},
+		{"typo without fuzzy", RomQuery{Text: "super maro"}, 0},
+		{"typo with fuzzy", RomQuery{Text: "super maro", Fuzzy: true}, 1},
+		{"no text", RomQuery{}, 2},
+	}
+	for _, tt := range tests {
+		if got := searchNames(tt.query); len(got) != tt.want {
+			t.Errorf("%s: search = %q, want %d results", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLibraryIndexSeesNewRomAfterInvalidate(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeTestRom(t, "snes", "A.sfc", "x")
+	if got := searchNames(RomQuery{}); len(got) != 1 {
+		t.Fatalf("search = %q", got)
+	}
+	// 폴더를 다시 훑는 주기 안이라도 무효화하면 바로 반영
+	writeTestRom(t, "snes", "B.sfc", "x")
+	if got := searchNames(RomQuery{}); len(got) != 2 {
+		t.Errorf("search after adding a rom = %q", got)
+	}
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	playStatsFile     = "./data/playstats.json"
+	sessionTimeout    = 3 * time.Minute // 하트비트가 끊긴 세션을 종료로 간주하는 시간
+	sessionSectionMax = 8               // SSR 상단 섹션에 보여줄 카드 수
+	// 하트비트로 쌓인 플레이 시간을 파일에 쓰는 주기 (세션 시작/종료는 바로 저장)
+	playStatsFlushInterval = time.Minute
+	defaultUser            = "guest"
+)
+
+// [플레이 통계] 사용자 + 롬 단위 누적 기록
+type PlayStat struct {
+	User         string `json:"user"`
+	System       string `json:"system"`
+	Rom          string `json:"rom"`
+	TotalSeconds int64  `json:"totalSeconds"`
+	PlayCount    int    `json:"playCount"`
+	LastPlayed   int64  `json:"lastPlayed"`
+}
+
+type PlaySession struct {
+	ID       string    `json:"id"`
+	User     string    `json:"user"`
+	System   string    `json:"system"`
+	Rom      string    `json:"rom"`
+	Started  time.Time `json:"started"`
+	LastBeat time.Time `json:"lastBeat"`
+	Credited time.Time `json:"credited"` // 이 시각까지의 플레이 시간은 통계에 누적됨
+}
+
+// [API 응답] 이어하기 / 많이 한 게임 목록의 한 항목
+type PlayStatView struct {
+	System       string `json:"system"`
+	Rom          string `json:"rom"`
+	Title        string `json:"title"`
+	Image        string `json:"image,omitempty"`
+	TotalSeconds int64  `json:"totalSeconds"`
+	PlayCount    int    `json:"playCount"`
+	LastPlayed   int64  `json:"lastPlayed"`
+}
+
+var playSessions struct {
+	sync.Mutex
+	active map[string]*PlaySession
+	stats  map[string]*PlayStat // key: user|sys/rom
+	loaded bool
+	dirty  bool // 저장하지 않은 변경(하트비트로 누적한 시간)이 있음
+}
+
+// 플레이 통계 버전. 라이브러리 인덱스는 이 값이 바뀌면 통계 필드만 다시 채운다
+var playStatsVersion atomic.Int64
+
+var reUserName = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,32}$`)
+
+// 요청한 사용자 이름: ?user= 또는 X-Retro-User 헤더, 없거나 형식이 맞지 않으면 guest
+func requestUser(r *http.Request) string {
+	user := r.URL.Query().Get("user")
+	if user == "" {
+		user = r.Header.Get("X-Retro-User")
+	}
+	if !reUserName.MatchString(user) {
+		return defaultUser
+	}
+	return user
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func playStatKey(user, sys, rom string) string {
+	return user + "|" + sys + "/" + rom
+}
+
+// playSessions 잠금 상태에서 호출
+func ensurePlayStatsLoaded() {
+	if playSessions.active == nil {
+		playSessions.active = make(map[string]*PlaySession)
+	}
+	if playSessions.loaded {
+		return
+	}
+	playSessions.stats = make(map[string]*PlayStat)
+	var list []*PlayStat
+	if _, err := playStatsStore.Load(&list); err != nil {
+		// 손상된 통계 파일을 빈 값으로 덮어쓰지 않도록 로드 실패 상태로 남김
+		return
+	}
+	for _, st := range list {
+		playSessions.stats[playStatKey(st.User, st.System, st.Rom)] = st
+	}
+	playSessions.loaded = true
+}
+
+// playSessions 잠금 상태에서 호출. 통계 파일을 읽지 못한 상태에서는 덮어쓰지 않음
+func savePlayStats() {
+	if !playSessions.loaded {
+		return
+	}
+	list := make([]*PlayStat, 0, len(playSessions.stats))
+	for _, st := range playSessions.stats {
+		list = append(list, st)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return playStatKey(list[i].User, list[i].System, list[i].Rom) < playStatKey(list[j].User, list[j].System, list[j].Rom)
+	})
+	if err := playStatsStore.Save(list); err != nil {
+		logger("session").Error("통계 저장 실패", "err", err)
+		return
+	}
+	playSessions.dirty = false
+}
+
+// 통계가 바뀌면 라이브러리 정렬(most played)과 SSR 상단 섹션도 갱신되어야 함
+// 폴더를 다시 훑지 않도록 인덱스는 통계 필드만 다시 채우고, SSR 은 새 인덱스를 보고 다시 만든다
+func onPlayStatsChanged() {
+	playStatsVersion.Add(1)
+}
+
+// 마지막으로 누적한 시각부터 until 까지를 플레이 시간에 더함
+// 하트비트는 메모리에만 누적하고 playStatsFlushInterval 마다 저장하므로
+// 서버가 재시작되어도 진행 중이던 시간은 최대 그 주기만큼만 잃음
+// playSessions 잠금 상태에서 호출
+func creditSession(sess *PlaySession, until time.Time) {
+	st := playSessions.stats[playStatKey(sess.User, sess.System, sess.Rom)]
+	if st == nil {
+		return
+	}
+	if d := until.Sub(sess.Credited); d > 0 {
+		st.TotalSeconds += int64(d.Seconds())
+		sess.Credited = sess.Credited.Add(d.Truncate(time.Second))
+	}
+	st.LastPlayed = until.Unix()
+	playSessions.dirty = true
+}
+
+// 세션 종료 처리: 마지막 하트비트(또는 종료 시각)까지를 플레이 시간으로 누적
+// playSessions 잠금 상태에서 호출
+func finishSession(sess *PlaySession, end time.Time) {
+	delete(playSessions.active, sess.ID)
+	creditSession(sess, end)
+}
+
+// POST /api/session/start?sys=&rom= | /api/session/heartbeat?id= | /api/session/end?id=
+func handleSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	action := strings.TrimPrefix(r.URL.Path, "/api/session/")
+	if changed := updateSession(w, r, action); changed {
+		onPlayStatsChanged()
+	}
+}
+
+// 세션 상태 변경 후 통계가 바뀌었으면 true (저장까지 완료된 상태)
+func updateSession(w http.ResponseWriter, r *http.Request, action string) bool {
+	playSessions.Lock()
+	defer playSessions.Unlock()
+	ensurePlayStatsLoaded()
+	now := time.Now()
+
+	switch action {
+	case "start":
+		sys := r.URL.Query().Get("sys")
+		rom := r.URL.Query().Get("rom")
+		if sys == "" || rom == "" {
+			http.Error(w, "Missing params", 400)
+			return false
+		}
+		if !romExists(sys, rom) {
+			http.Error(w, "Unknown rom", 404)
+			return false
+		}
+		sess := &PlaySession{ID: newID(), User: requestUser(r), System: sys, Rom: rom, Started: now, LastBeat: now, Credited: now}
+		playSessions.active[sess.ID] = sess
+
+		key := playStatKey(sess.User, sys, rom)
+		st := playSessions.stats[key]
+		if st == nil {
+			st = &PlayStat{User: sess.User, System: sys, Rom: rom}
+			playSessions.stats[key] = st
+		}
+		st.PlayCount++
+		st.LastPlayed = now.Unix()
+		savePlayStats()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"id": sess.ID})
+		return true
+
+	case "heartbeat":
+		sess := playSessions.active[r.URL.Query().Get("id")]
+		if sess == nil {
+			http.Error(w, "Unknown session", 404)
+			return false
+		}
+		sess.LastBeat = now
+		creditSession(sess, now) // 저장은 playSessionReaper 가 모아서 함
+		w.WriteHeader(200)
+		return false
+
+	case "end":
+		sess := playSessions.active[r.URL.Query().Get("id")]
+		if sess == nil {
+			// 이미 만료 처리된 세션일 수 있음
+			w.WriteHeader(200)
+			return false
+		}
+		finishSession(sess, now)
+		savePlayStats()
+		w.WriteHeader(200)
+		return true
+	}
+	http.NotFound(w, r)
+	return false
+}
+
+// 하트비트가 끊긴 세션(탭 강제 종료 등)을 정리하고, 하트비트로 쌓인 통계를 저장
+func playSessionReaper() {
+	ticker := time.NewTicker(playStatsFlushInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if expired, flushed := reapPlaySessions(time.Now()); flushed {
+			if expired > 0 {
+				logger("session").Info("응답 없는 세션 종료 처리", "count", expired)
+			}
+			onPlayStatsChanged()
+		}
+	}
+}
+
+// 만료된 세션 수와 통계를 저장했는지
+func reapPlaySessions(now time.Time) (int, bool) {
+	playSessions.Lock()
+	defer playSessions.Unlock()
+	ensurePlayStatsLoaded()
+	expired := 0
+	for _, sess := range playSessions.active {
+		if now.Sub(sess.LastBeat) > sessionTimeout {
+			finishSession(sess, sess.LastBeat)
+			expired++
+		}
+	}
+	if !playSessions.dirty {
+		return expired, false
+	}
+	savePlayStats()
+	return expired, true
+}
+
+// 롬 단위로 합산한 통계 (user 가 비어 있으면 전체 사용자). 지워진 롬은 제외
+func aggregatePlayStats(user string) []PlayStatView {
+	playSessions.Lock()
+	ensurePlayStatsLoaded()
+	merged := make(map[string]*PlayStatView)
+	for _, st := range playSessions.stats {
+		if user != "" && st.User != user {
+			continue
+		}
+		key := st.System + "/" + st.Rom
+		v := merged[key]
+		if v == nil {
+			v = &PlayStatView{System: st.System, Rom: st.Rom}
+			merged[key] = v
+		}
+		v.TotalSeconds += st.TotalSeconds
+		v.PlayCount += st.PlayCount
+		if st.LastPlayed > v.LastPlayed {
+			v.LastPlayed = st.LastPlayed
+		}
+	}
+	playSessions.Unlock()
+
+	var out []PlayStatView
+	for _, v := range merged {
+		if !romExists(v.System, v.Rom) {
+			continue
+		}
+		v.Title = strings.TrimSuffix(v.Rom, filepath.Ext(v.Rom))
+		v.Image = cardImageURL(v.System, v.Rom)
+		out = append(out, *v)
+	}
+	return out
+}
+
+func continuePlaying(user string, limit int) []PlayStatView {
+	list := aggregatePlayStats(user)
+	sort.Slice(list, func(i, j int) bool { return list[i].LastPlayed > list[j].LastPlayed })
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list
+}
+
+func mostPlayed(user string, limit int) []PlayStatView {
+	list := aggregatePlayStats(user)
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].TotalSeconds != list[j].TotalSeconds {
+			return list[i].TotalSeconds > list[j].TotalSeconds
+		}
+		return list[i].PlayCount > list[j].PlayCount
+	})
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list
+}
+
+// GET /api/stats/continue | /api/stats/top  (?user= 지정 시 해당 사용자만, limit 기본 10)
+func handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	limit := 10
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid limit", 400)
+			return
+		}
+		limit = n
+	}
+	user := ""
+	if r.URL.Query().Get("user") != "" || r.Header.Get("X-Retro-User") != "" {
+		user = requestUser(r)
+	}
+
+	var list []PlayStatView
+	switch strings.TrimPrefix(r.URL.Path, "/api/stats/") {
+	case "continue":
+		list = continuePlaying(user, limit)
+	case "top":
+		list = mostPlayed(user, limit)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if list == nil {
+		list = []PlayStatView{}
+	}
+	writeJSONWithETag(w, r, list)
+}
+
+// 롬별 통계 조회용 (라이브러리 인덱스 생성 시 사용)
+func playStatsByRom() map[string]PlayStatView {
+	out := make(map[string]PlayStatView)
+	for _, v := range aggregatePlayStats("") {
+		out[v.System+"/"+v.Rom] = v
+	}
+	return out
+}
+
+// [SSR] 라이브러리 상단의 "이어하기" / "많이 한 게임" 섹션
+func generatePlaySectionsHTML(library *libraryIndex) string {
+	sizes := make(map[string]int64)
+	for _, e := range library.entries {
+		sizes[e.System+"/"+e.Name] = e.Size
+	}
+
+	var sb strings.Builder
+	writeSection := func(title, color string, list []PlayStatView) {
+		if len(list) == 0 {
+			return
+		}
+		sb.WriteString(fmt.Sprintf(`<div class="category"><div class="category-title" style="border-left-color: %s;">%s <span class="game-count">(%d)</span></div><div class="rom-grid">`, color, title, len(list)))
+		for _, v := range list {
+			writeCardHTML(&sb, v.System, v.Rom, sizes[v.System+"/"+v.Rom])
+		}
+		sb.WriteString(`</div></div>`)
+	}
+	writeSection("▶ 이어하기", "#7CB342", continuePlaying("", sessionSectionMax))
+	writeSection("🔥 많이 한 게임", "#F57C00", mostPlayed("", sessionSectionMax))
+	return sb.String()
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func resetPlaySessions() {
+	playSessions.Lock()
+	playSessions.active, playSessions.stats = nil, nil
+	playSessions.loaded, playSessions.dirty = false, false
+	playSessions.Unlock()
+}
+
+func postSession(t *testing.T, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handleSession(w, httptest.NewRequest("POST", target, nil))
+	if w.Code != 200 {
+		t.Fatalf("POST %s: status %d (%s)", target, w.Code, w.Body)
+	}
+	return w
+}
+
+func savedPlayStat(t *testing.T) PlayStat {
+	t.Helper()
+	var list []PlayStat
+	if _, err := playStatsStore.Load(&list); err != nil || len(list) != 1 {
+		t.Fatalf("playstats.json = %+v, %v", list, err)
+	}
+	return list[0]
+}
+
+func TestPlaySessionHeartbeatsFlushOnTimer(t *testing.T) {
+	t.Chdir(t.TempDir())
+	resetPlaySessions()
+	writeTestRom(t, "snes", "Game.sfc", "x")
+
+	var started map[string]string
+	json.Unmarshal(postSession(t, "/api/session/start?sys=snes&rom=Game.sfc&user=alice").Body.Bytes(), &started)
+	id := started["id"]
+	if st := savedPlayStat(t); st.PlayCount != 1 || st.User != "alice" {
+		t.Fatalf("after start: %+v", st)
+	}
+	before := getLibraryIndex()
+
+	// 30초 전에 시작한 것처럼
+	playSessions.Lock()
+	playSessions.active[id].Credited = time.Now().Add(-30 * time.Second)
+	playSessions.Unlock()
+	version := playStatsVersion.Load()
+	postSession(t, "/api/session/heartbeat?id="+id)
+
+	if st := savedPlayStat(t); st.TotalSeconds != 0 {
+		t.Errorf("heartbeat wrote playstats.json: %+v", st)
+	}
+	if playStatsVersion.Load() != version {
+		t.Errorf("heartbeat changed the stats version")
+	}
+
+	if _, flushed := reapPlaySessions(time.Now()); !flushed {
+		t.Fatal("reaper did not flush accrued time")
+	}
+	onPlayStatsChanged()
+	if st := savedPlayStat(t); st.TotalSeconds < 30 {
+		t.Errorf("after flush: %+v, want at least 30s", st)
+	}
+	if _, flushed := reapPlaySessions(time.Now()); flushed {
+		t.Errorf("reaper flushed again with nothing new")
+	}
+
+	after := getLibraryIndex()
+	if len(after.entries) != 1 || after.entries[0].PlayTime < 30 {
+		t.Fatalf("library entry after flush = %+v", after.entries)
+	}
+	if &after.saveTimes[0] != &before.saveTimes[0] {
+		t.Errorf("library index was rebuilt for a stats-only change")
+	}
+	if before.entries[0].PlayTime != 0 {
+		t.Errorf("stats refresh modified the previous index in place")
+	}
+
+	postSession(t, "/api/session/end?id="+id)
+	playSessions.Lock()
+	active := len(playSessions.active)
+	playSessions.Unlock()
+	if active != 0 {
+		t.Errorf("%d sessions still active after end", active)
+	}
+}
+
+func TestPlaySessionReaperExpiresSilentSessions(t *testing.T) {
+	t.Chdir(t.TempDir())
+	resetPlaySessions()
+	writeTestRom(t, "snes", "Game.sfc", "x")
+	postSession(t, "/api/session/start?sys=snes&rom=Game.sfc")
+
+	expired, flushed := reapPlaySessions(time.Now().Add(sessionTimeout + time.Minute))
+	if expired != 1 || !flushed {
+		t.Errorf("reapPlaySessions = %d, %v; want 1, true", expired, flushed)
+	}
+}
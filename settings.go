@@ -0,0 +1,297 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// [에뮬레이터 설정] 전체 → 시스템 → 게임 순으로 덮어쓰는 설정 (코어 옵션, 조작 배치, 셰이더, 스레드, 자동 저장 주기)
+// 게임 키는 "<시스템 폴더>/<롬 파일 이름>" 이며 * ? 패턴을 쓸 수 있다 (예: "mame/SF*")
+const settingsFile = "./data/settings.json"
+
+var settingsStore = &jsonStore{path: settingsFile}
+
+var settingsMu sync.Mutex
+
+type EmulatorSettings struct {
+	Options       map[string]string  `json:"options,omitempty"`       // EJS_defaultOptions (코어 옵션 포함)
+	ControlPreset string             `json:"controlPreset,omitempty"` // controlPresets 이름
+	Controls      map[int]ControlMap `json:"controls,omitempty"`      // 플레이어 → 버튼 번호 → 배치 (프리셋 위에 덮어씀)
+	Shader        string             `json:"shader,omitempty"`        // "disabled" 면 셰이더 끔
+	Threads       *bool              `json:"threads,omitempty"`
+	SaveInterval  int                `json:"saveInterval,omitempty"` // 초. 0 이면 위 단계 값
+}
+
+type SettingsFile struct {
+	Global  EmulatorSettings            `json:"global"`
+	Systems map[string]EmulatorSettings `json:"systems"`
+	Games   map[string]EmulatorSettings `json:"games"`
+}
+
+// [API 응답] 런처에 넘길 최종 설정과 적용된 단계
+type ResolvedSettings struct {
+	System        string             `json:"system"`
+	Rom           string             `json:"rom,omitempty"`
+	Options       map[string]string  `json:"options"`
+	ControlPreset string             `json:"controlPreset"`
+	Controls      map[int]ControlMap `json:"controls"`
+	Shader        string             `json:"shader"`
+	Threads       bool               `json:"threads"`
+	SaveInterval  int                `json:"saveInterval"`
+	Layers        []string           `json:"layers"`
+}
+
+const defaultSaveInterval = 120
+
+// 설정 파일이 없을 때의 값 (기존 런처에 고정되어 있던 값)
+func defaultSettingsFile() SettingsFile {
+	return SettingsFile{
+		Global: EmulatorSettings{
+			Options: map[string]string{
+				"save-state-location": "memory",
+				"worker":              "false",
+				"webgl2Enabled":       "enabled",
+			},
+			Shader:       "crt-easymode.glslp",
+			SaveInterval: defaultSaveInterval,
+		},
+		Systems: map[string]EmulatorSettings{},
+		Games: map[string]EmulatorSettings{
+			"mame/SF*": {ControlPreset: "capcom6"},
+		},
+	}
+}
+
+// settingsMu 잠금 상태에서 호출
+func loadSettings() (SettingsFile, error) {
+	var file SettingsFile
+	found, err := settingsStore.Load(&file)
+	if err != nil {
+		return file, err
+	}
+	if !found {
+		return defaultSettingsFile(), nil
+	}
+	if file.Systems == nil {
+		file.Systems = map[string]EmulatorSettings{}
+	}
+	if file.Games == nil {
+		file.Games = map[string]EmulatorSettings{}
+	}
+	return file, nil
+}
+
+func (r *ResolvedSettings) apply(s EmulatorSettings) {
+	for k, v := range s.Options {
+		r.Options[k] = v
+	}
+	if s.ControlPreset != "" {
+		r.ControlPreset = s.ControlPreset
+	}
+	for player, buttons := range s.Controls {
+		if r.Controls[player] == nil {
+			r.Controls[player] = ControlMap{}
+		}
+		for button, c := range buttons {
+			r.Controls[player][button] = c
+		}
+	}
+	if s.Shader != "" {
+		r.Shader = s.Shader
+	}
+	if s.Threads != nil {
+		r.Threads = *s.Threads
+	}
+	if s.SaveInterval > 0 {
+		r.SaveInterval = s.SaveInterval
+	}
+}
+
+// 롬에 맞는 게임 키 (패턴 먼저 짧은 순, 정확히 같은 키는 마지막 → 구체적인 쪽이 우선)
+func matchingGameKeys(games map[string]EmulatorSettings, sys, rom string) []string {
+	target := sys + "/" + rom
+	var keys []string
+	for key := range games {
+		if ok, _ := path.Match(key, target); ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ei, ej := keys[i] == target, keys[j] == target
+		if ei != ej {
+			return ej
+		}
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) < len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// 코어 목록의 기본값(스레드, 조작 프리셋) → 전체 → 시스템 → 게임
+func resolveSettings(file SettingsFile, config Config, sys, rom string) ResolvedSettings {
+	info, _ := lookupSystem(config, sys)
+	res := ResolvedSettings{
+		System:        sys,
+		Rom:           rom,
+		Options:       map[string]string{},
+		ControlPreset: info.Controls,
+		Controls:      map[int]ControlMap{},
+		Threads:       info.Threads,
+		SaveInterval:  defaultSaveInterval,
+		Layers:        []string{"core"},
+	}
+	if res.ControlPreset == "" {
+		res.ControlPreset = "standard"
+	}
+	res.apply(file.Global)
+	res.Layers = append(res.Layers, "global")
+	if s, ok := file.Systems[sys]; ok {
+		res.apply(s)
+		res.Layers = append(res.Layers, "system:"+sys)
+	}
+	if rom != "" {
+		for _, key := range matchingGameKeys(file.Games, sys, rom) {
+			res.apply(file.Games[key])
+			res.Layers = append(res.Layers, "game:"+key)
+		}
+	}
+
+	// 프리셋 위에 직접 지정한 버튼을 덮어씀
+	controls := defaultControls(res.ControlPreset)
+	for player, buttons := range res.Controls {
+		merged := ControlMap{}
+		for button, c := range controls[player] {
+			merged[button] = c
+		}
+		for button, c := range buttons {
+			merged[button] = c
+		}
+		controls[player] = merged
+	}
+	res.Controls = controls
+	return res
+}
+
+func validateSettings(s EmulatorSettings) error {
+	if _, ok := controlPresets[s.ControlPreset]; s.ControlPreset != "" && !ok {
+		return fmt.Errorf("unknown controlPreset: %s", s.ControlPreset)
+	}
+	if s.SaveInterval < 0 || (s.SaveInterval > 0 && s.SaveInterval < 10) {
+		return fmt.Errorf("saveInterval must be 0 or at least 10 seconds")
+	}
+	for player := range s.Controls {
+		if player < 0 || player > 3 {
+			return fmt.Errorf("invalid player: %d", player)
+		}
+	}
+	return nil
+}
+
+// 게임 키: "<시스템>/<롬 파일 이름 또는 패턴>"
+func validGameKey(key string) bool {
+	sys, rom, ok := strings.Cut(key, "/")
+	if !ok || sys == "" || rom == "" || strings.Contains(rom, "/") || strings.HasPrefix(sys, ".") {
+		return false
+	}
+	_, err := path.Match(key, "")
+	return err == nil
+}
+
+// GET    /api/settings                          전체 설정 파일 + 조작 프리셋 이름
+// GET    /api/settings?sys=&rom=                 런처용 최종 설정
+// PUT    /api/settings?layer=global              (본문: 설정, 관리 API)
+// PUT    /api/settings?layer=system&key=mame
+// PUT    /api/settings?layer=game&key=mame/SF*
+// DELETE /api/settings?layer=...&key=...         단계 삭제 (global 은 비움)
+func handleSettings(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	switch r.Method {
+	case "GET":
+		settingsMu.Lock()
+		file, err := loadSettings()
+		settingsMu.Unlock()
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if sys := q.Get("sys"); sys != "" {
+			rom := q.Get("rom")
+			if rom != "" {
+				rom = filepath.Base(rom)
+			}
+			json.NewEncoder(w).Encode(resolveSettings(file, loadConfigFromHTML(), filepath.Base(sys), rom))
+			return
+		}
+		presets := make([]string, 0, len(controlPresets))
+		for name := range controlPresets {
+			presets = append(presets, name)
+		}
+		sort.Strings(presets)
+		json.NewEncoder(w).Encode(map[string]interface{}{"settings": file, "controlPresets": presets})
+
+	case "PUT", "DELETE":
+		if !requireAdmin(w, r) {
+			return
+		}
+		layer, key := q.Get("layer"), q.Get("key")
+		switch {
+		case layer == "global":
+		case layer == "system" && validRomName(key):
+		case layer == "game" && validGameKey(key):
+		default:
+			http.Error(w, "Invalid layer or key", 400)
+			return
+		}
+		var s EmulatorSettings
+		if r.Method == "PUT" {
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&s); err != nil {
+				http.Error(w, "Invalid JSON", 400)
+				return
+			}
+			if err := validateSettings(s); err != nil {
+				http.Error(w, err.Error(), 400)
+				return
+			}
+		}
+
+		settingsMu.Lock()
+		defer settingsMu.Unlock()
+		file, err := loadSettings()
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		switch {
+		case layer == "global":
+			file.Global = s
+		case r.Method == "DELETE" && layer == "system":
+			delete(file.Systems, key)
+		case r.Method == "DELETE":
+			delete(file.Games, key)
+		case layer == "system":
+			file.Systems[key] = s
+		default:
+			file.Games[key] = s
+		}
+		if err := settingsStore.Save(file); err != nil {
+			logger("settings").Error("설정 저장 실패", "err", err)
+			http.Error(w, "Save failed", 500)
+			return
+		}
+		logger("settings").Info("설정 변경", "method", r.Method, "layer", layer, "key", key)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(file)
+
+	default:
+		http.Error(w, "Method not allowed", 405)
+	}
+}
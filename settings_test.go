@@ -0,0 +1,56 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchingGameKeys(t *testing.T) {
+	games := map[string]EmulatorSettings{
+		"snes/Mario.sfc":   {},
+		"snes/*":           {},
+		"snes/Mario*":      {},
+		"snes/Mario*.sfc":  {},
+		"nes/Mario.nes":    {},
+		"*/Mario.sfc":      {},
+		"snes/Zelda*.sfc":  {},
+		"snes/[MZ]ario.sf": {},
+	}
+	tests := []struct {
+		sys, rom string
+		want     []string
+	}{
+		{"snes", "Mario.sfc", []string{"snes/*", "*/Mario.sfc", "snes/Mario*", "snes/Mario*.sfc", "snes/Mario.sfc"}},
+		{"snes", "Zelda.sfc", []string{"snes/*", "snes/Zelda*.sfc"}},
+		{"nes", "Mario.nes", []string{"nes/Mario.nes"}},
+		{"gba", "Mario.gba", nil},
+	}
+	for _, tt := range tests {
+		if got := matchingGameKeys(games, tt.sys, tt.rom); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("matchingGameKeys(%q, %q) = %q, want %q", tt.sys, tt.rom, got, tt.want)
+		}
+	}
+}
+
+func TestValidGameKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"snes/Mario.sfc", true},
+		{"snes/*", true},
+		{"snes/Mario (USA).sfc", true},
+		{"*/Mario.sfc", true},
+		{"snes", false},
+		{"snes/", false},
+		{"/Mario.sfc", false},
+		{"snes/sub/Mario.sfc", false},
+		{".hidden/Mario.sfc", false},
+		{"snes/[Mario", false},
+	}
+	for _, tt := range tests {
+		if got := validGameKey(tt.key); got != tt.want {
+			t.Errorf("validGameKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
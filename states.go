@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const statesDir = "./data/states"
+
+var reStateSlot = regexp.MustCompile(`^[A-Za-z0-9_-]{1,16}$`)
+
+// [상태 저장 슬롯] data/states/<sys>/<rom>/<slot>.state + .json(메타) + .jpg(썸네일)
+type StateSlotMeta struct {
+	Slot         string `json:"slot"`
+	Size         int64  `json:"size"`
+	MD5          string `json:"md5"`
+	Core         string `json:"core,omitempty"`
+	CoreVersion  string `json:"coreVersion,omitempty"`
+	User         string `json:"user"`
+	Updated      int64  `json:"updated"`
+	HasThumbnail bool   `json:"hasThumbnail"`
+}
+
+func stateSlotDir(sys, rom string) string {
+	return filepath.Join(statesDir, filepath.Base(sys), filepath.Base(rom))
+}
+
+// GET    /api/states/<sys>/<rom>             슬롯 목록
+// PUT    /api/states/<sys>/<rom>/<slot>      저장 (multipart: state, screenshot, core, coreVersion 또는 raw body + X-Core 헤더)
+// GET    /api/states/<sys>/<rom>/<slot>      상태 파일 다운로드
+// DELETE /api/states/<sys>/<rom>/<slot>
+// GET    /api/states/<sys>/<rom>/<slot>/thumb
+func handleStates(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/states/"), "/"), "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		http.Error(w, "Missing params", 400)
+		return
+	}
+	sys, rom := filepath.Base(parts[0]), filepath.Base(parts[1])
+	if !romExists(sys, rom) {
+		http.Error(w, "Unknown rom", 404)
+		return
+	}
+	if len(parts) == 2 {
+		if r.Method != "GET" {
+			http.Error(w, "Method not allowed", 405)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(listStateSlots(sys, rom))
+		return
+	}
+
+	slot := parts[2]
+	if !reStateSlot.MatchString(slot) {
+		http.Error(w, "Invalid slot", 400)
+		return
+	}
+	dir := stateSlotDir(sys, rom)
+
+	if len(parts) == 4 && parts[3] == "thumb" {
+		thumb := filepath.Join(dir, slot+".jpg")
+		if _, err := os.Stat(thumb); err != nil {
+			http.Error(w, "Not found", 404)
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		http.ServeFile(w, r, thumb)
+		return
+	}
+	if len(parts) != 3 {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		path := filepath.Join(dir, slot+".state")
+		if _, err := os.Stat(path); err != nil {
+			http.Error(w, "Not found", 404)
+			return
+		}
+		if meta, err := readStateMeta(dir, slot); err == nil {
+			w.Header().Set("X-Core", meta.Core)
+			w.Header().Set("X-Core-Version", meta.CoreVersion)
+			w.Header().Set("X-State-Updated", strconv.FormatInt(meta.Updated, 10))
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		http.ServeFile(w, r, path)
+
+	case "PUT":
+		putStateSlot(w, r, sys, rom, slot)
+
+	case "DELETE":
+		for _, ext := range []string{".state", ".json", ".jpg"} {
+			os.Remove(filepath.Join(dir, slot+ext))
+		}
+		noteStateUsage(dir)
+		w.WriteHeader(200)
+
+	default:
+		http.Error(w, "Method not allowed", 405)
+	}
+}
+
+func putStateSlot(w http.ResponseWriter, r *http.Request, sys, rom, slot string) {
+	var state, screenshot []byte
+	meta := StateSlotMeta{Slot: slot, User: requestUser(r)}
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		r.Body = http.MaxBytesReader(w, r.Body, maxStateBytes+maxScreenshotBytes)
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			if isTooLarge(err) {
+				writeUploadError(w, err, 400)
+			} else {
+				http.Error(w, "Invalid form", 400)
+			}
+			return
+		}
+		state = readFormFile(r, "state")
+		screenshot = readFormFile(r, "screenshot")
+		meta.Core = r.FormValue("core")
+		meta.CoreVersion = r.FormValue("coreVersion")
+	} else {
+		var err error
+		if state, err = io.ReadAll(http.MaxBytesReader(w, r.Body, maxStateBytes)); err != nil {
+			writeUploadError(w, err, 400)
+			return
+		}
+		meta.Core = r.Header.Get("X-Core")
+		meta.CoreVersion = r.Header.Get("X-Core-Version")
+	}
+	if len(state) == 0 {
+		http.Error(w, "Empty state", 400)
+		return
+	}
+	if len(state) > maxStateBytes || len(screenshot) > maxScreenshotBytes {
+		http.Error(w, "Payload too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err := checkStorageQuota(meta.User, int64(len(state))); err != nil {
+		logger("state").Warn("저장 거부", "sys", sys, "rom", rom, "slot", slot, "user", meta.User, "err", err)
+		writeUploadError(w, err, 500)
+		return
+	}
+
+	meta, err := writeStateSlot(sys, rom, state, screenshot, meta)
+	if err != nil {
+		logger("state").Error("저장 실패", "sys", sys, "rom", rom, "slot", slot, "err", err)
+		http.Error(w, "Write failed", 500)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(meta)
+}
+
+// 상태 파일 + 썸네일 + 메타 저장. meta.Slot / User / Core 는 호출하는 쪽에서 채움
+func writeStateSlot(sys, rom string, state, screenshot []byte, meta StateSlotMeta) (StateSlotMeta, error) {
+	dir := stateSlotDir(sys, rom)
+	if err := writeFileAtomic(filepath.Join(dir, meta.Slot+".state"), state, false); err != nil {
+		return meta, err
+	}
+
+	thumb := filepath.Join(dir, meta.Slot+".jpg")
+	os.Remove(thumb)
+	meta.HasThumbnail = false
+	if len(screenshot) > 0 {
+		if img, _, err := image.Decode(bytes.NewReader(screenshot)); err == nil {
+			if err := writeThumbnail(img, thumb); err == nil {
+				meta.HasThumbnail = true
+			}
+		}
+	}
+
+	meta.Size = int64(len(state))
+	meta.MD5 = md5Hex(state)
+	meta.Updated = time.Now().Unix()
+	data, _ := json.MarshalIndent(meta, "", "  ")
+	err := writeFileAtomic(filepath.Join(dir, meta.Slot+".json"), data, false)
+	noteStateUsage(dir)
+	return meta, err
+}
+
+func readFormFile(r *http.Request, field string) []byte {
+	f, _, err := r.FormFile(field)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	data, _ := io.ReadAll(f)
+	return data
+}
+
+func readStateMeta(dir, slot string) (StateSlotMeta, error) {
+	var meta StateSlotMeta
+	data, err := os.ReadFile(filepath.Join(dir, slot+".json"))
+	if err != nil {
+		return meta, err
+	}
+	err = json.Unmarshal(data, &meta)
+	return meta, err
+}
+
+func listStateSlots(sys, rom string) []StateSlotMeta {
+	dir := stateSlotDir(sys, rom)
+	entries, _ := os.ReadDir(dir)
+	slots := []StateSlotMeta{}
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), ".state") {
+			continue
+		}
+		slot := strings.TrimSuffix(e.Name(), ".state")
+		meta, err := readStateMeta(dir, slot)
+		if err != nil {
+			// 메타가 없으면 파일 정보만으로 채움
+			meta = StateSlotMeta{Slot: slot}
+			if info, err := e.Info(); err == nil {
+				meta.Size = info.Size()
+				meta.Updated = info.ModTime().Unix()
+			}
+		}
+		slots = append(slots, meta)
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i].Slot < slots[j].Slot })
+	return slots
+}
@@ -9,12 +9,15 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"html"
 	"io"
-	"log"
+	"io/fs"
+	"log/slog"
 	"math"
 	"net/http"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -33,7 +36,10 @@ type Config struct {
 }
 
 type SyncInfo struct {
-	LastSyncTime int64 `json:"lastSyncTime"`
+	LastSyncTime  int64  `json:"lastSyncTime"`
+	LastResult    string `json:"lastResult,omitempty"` // [추가] success / partial / failed
+	LastTotal     int    `json:"lastTotal,omitempty"`
+	LastSucceeded int    `json:"lastSucceeded,omitempty"`
 }
 
 type BookmarkItem struct {
@@ -42,8 +48,10 @@ type BookmarkItem struct {
 }
 
 type RomInfo struct {
-	Name string `json:"name"`
-	Size int64  `json:"size"`
+	Name    string `json:"name"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"modTime,omitempty"`
+	Discs   int    `json:"discs,omitempty"` // [추가] .m3u 재생 목록의 디스크 수
 }
 
 type InjectLog map[string]string
@@ -61,14 +69,25 @@ var indexCache struct {
 	RomsDirTime   time.Time
 	IndexFileTime time.Time
 	IndexFileSize int64     // [추가] index.html 파일 크기 (변경 감지용)
+	Library       *libraryIndex // [추가] 렌더링에 사용한 검색 인덱스 (메타데이터/썸네일 변경 감지용)
 	ETag          string
 }
 
+// [추가] 롬 폴더 외의 변화(썸네일 등)로 SSR 결과가 바뀔 때 다음 요청에서 재생성하도록 함
+func invalidateIndexCache() {
+	indexCache.Lock()
+	indexCache.RomsDirTime = time.Time{}
+	indexCache.Unlock()
+}
+
 // index.html에서 설정을 읽어옴
 func loadConfigFromHTML() Config {
 	config := Config{Systems: make(map[string]string)}
 	file, err := os.Open("index.html")
-	if err != nil { return config }
+	if err != nil {
+		logger("config").Error("index.html 읽기 실패", "err", err)
+		return config
+	}
 	defer file.Close()
 	scanner := bufio.NewScanner(file)
 	inMap := false
@@ -89,14 +108,21 @@ func loadConfigFromHTML() Config {
 
 func getDiskUsage(path string) (uint64, uint64) {
 	var stat syscall.Statfs_t
-	syscall.Statfs(path, &stat)
+	if err := syscall.Statfs(path, &stat); err != nil {
+		logger("disk").Warn("디스크 용량 조회 실패", "path", path, "err", err)
+	}
 	free := stat.Bavail * uint64(stat.Bsize)
 	total := stat.Blocks * uint64(stat.Bsize)
 	return free, total
 }
 
 func handleDiskInfo(w http.ResponseWriter, r *http.Request) {
-	wd, _ := os.Getwd()
+	wd, err := os.Getwd()
+	if err != nil {
+		logger("disk").Error("작업 폴더 조회 실패", "err", err)
+		http.Error(w, "Disk info failed", 500)
+		return
+	}
 	free, total := getDiskUsage(wd)
 	json.NewEncoder(w).Encode(map[string]uint64{"free": free, "total": total})
 }
@@ -121,42 +147,51 @@ func writeCardHTML(sb *strings.Builder, sys, romName string, size int64) {
 	safeSys := strings.ReplaceAll(sys, "'", "\\'")
 	safeRom := strings.ReplaceAll(romName, "'", "\\'")
 
+	// [추가] 박스아트 또는 스크린샷 썸네일이 있으면 카드 상단에 표시
+	thumbHTML := ""
+	if imgURL := cardImageURL(sys, romName); imgURL != "" {
+		thumbHTML = fmt.Sprintf(`<img class="rom-thumb" src="%s" loading="lazy" alt="">`, imgURL)
+	}
+
 	// 구조: .size-gauge(배경 그라데이션) > .gauge-cover(회색 가림막)
 	sb.WriteString(fmt.Sprintf(
 		`<div class="rom-card" data-sys="%s" data-rom="%s" onclick="Launcher.run('%s', '%s')" oncontextmenu="App.showCtx(event, '%s', '%s')" ontouchstart="App.handleTouch(event, '%s', '%s')">`+
-		`<span class="rom-name">%s</span>`+
+		`%s<span class="rom-name">%s</span>`+
 		`<div class="size-gauge"><div class="gauge-cover" style="width:%.1f%%"></div></div>`+
 		`</div>`, 
-		safeSys, safeRom, safeSys, safeRom, safeSys, safeRom, safeSys, safeRom, romNameDisp, coverPercent))
+		safeSys, safeRom, safeSys, safeRom, safeSys, safeRom, safeSys, safeRom, thumbHTML, romNameDisp, coverPercent))
 }
 
-// [수정] 롬 목록 HTML 생성기
-func generateRomHTML(baseDir string) string {
-	var sb strings.Builder
-	
-	// 1. 디렉토리 스캔 및 데이터 수집
+// [추가] 롬 디렉토리 스캔: 시스템별 롬 목록 (HTML/JSON 공용)
+func scanRomLibrary(baseDir string) map[string][]RomInfo {
 	romData := make(map[string][]RomInfo)
-	entries, _ := os.ReadDir(baseDir)
+	settings := loadScanSettings()
+	entries, err := os.ReadDir(baseDir)
+	if err != nil && !os.IsNotExist(err) {
+		logger("library").Error("롬 디렉토리 읽기 실패", "dir", baseDir, "err", err)
+	}
 	
 	for _, entry := range entries {
 		if entry.IsDir() {
 			sysName := entry.Name()
-			romEntries, _ := os.ReadDir(filepath.Join(baseDir, sysName))
-			var list []RomInfo
-			for _, rom := range romEntries {
-				if !rom.IsDir() {
-					ext := strings.ToLower(filepath.Ext(rom.Name()))
-					if ext == ".zip" || ext == ".7z" || ext == ".gba" || ext == ".nds" || ext == ".iso" || ext == ".bin" || ext == ".chd" || ext == ".sfc" || ext == ".smc" {
-						info, _ := rom.Info()
-						list = append(list, RomInfo{Name: rom.Name(), Size: info.Size()})
-					}
-				}
-			}
-			if len(list) > 0 {
+			// [수정] 하위 폴더, cue/bin, 시스템별 확장자 처리는 scanner.go
+			if list := scanSystemDir(baseDir, sysName, settings); len(list) > 0 {
 				romData[sysName] = list
 			}
 		}
 	}
+	return romData
+}
+
+// [수정] 롬 목록 HTML 생성기: 검색 인덱스 결과(entries)의 순서를 시스템 그룹 안에서 유지
+func generateRomHTML(entries []RomEntry, filtered bool) string {
+	var sb strings.Builder
+	
+	// 1. 시스템별 그룹화
+	romData := make(map[string][]RomEntry)
+	for _, e := range entries {
+		romData[e.System] = append(romData[e.System], e)
+	}
 
 	// 2. 시스템 이름 정렬
 	var systems []string
@@ -168,7 +203,6 @@ func generateRomHTML(baseDir string) string {
 	// 3. HTML 조립
 	for _, sys := range systems {
 		roms := romData[sys]
-		sort.Slice(roms, func(i, j int) bool { return roms[i].Name < roms[j].Name })
 
 		sb.WriteString(fmt.Sprintf(`<div class="category"><div class="category-title" style="border-left-color: #E55B5B;">%s <span class="game-count">(%d)</span></div><div class="rom-grid">`, sys, len(roms)))
 
@@ -179,38 +213,37 @@ func generateRomHTML(baseDir string) string {
 	}
 
 	if sb.Len() == 0 {
+		if filtered {
+			return `<div style="text-align:center; padding:50px; color:#aaa;">검색 결과가 없습니다.</div>`
+		}
 		return `<div style="text-align:center; padding:50px; color:#aaa;">게임 파일이 없습니다.<br>./data/roms 폴더에 게임을 넣어주세요.</div>`
 	}
 
 	return sb.String()
 }
 
-// Depth 1 변경 감지
+// [수정] 하위 폴더까지 변경 감지 (폴더 수정 시각만 확인)
 func getLatestModTime(baseDir string) time.Time {
 	latest := time.Time{}
-	info, err := os.Stat(baseDir)
-	if err != nil { return latest }
-	latest = info.ModTime()
+	if _, err := os.Stat(baseDir); err != nil { return latest }
 
-	entries, _ := os.ReadDir(baseDir)
-	for _, entry := range entries {
-		if entry.IsDir() {
-			subInfo, err := entry.Info()
-			if err == nil && subInfo.ModTime().After(latest) {
-				latest = subInfo.ModTime()
-			}
+	filepath.WalkDir(baseDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() { return nil }
+		if p != baseDir && strings.HasPrefix(d.Name(), ".") { return filepath.SkipDir }
+		if subInfo, err := d.Info(); err == nil && subInfo.ModTime().After(latest) {
+			latest = subInfo.ModTime()
 		}
-	}
+		return nil
+	})
 	return latest
 }
 
 // [수정] handleIndex: 파일 크기(Size)와 수정 시간(Time) 모두 체크하여 캐시 갱신
 func handleIndex(w http.ResponseWriter, r *http.Request) {
-	romsDir := "./data/roms"
 	indexFile := "index.html"
 
 	// 1. 변경 감지 (롬 폴더나 index.html이 바뀌었을 때만 갱신)
-	currentLatestTime := getLatestModTime(romsDir)
+	currentLatestTime := romsTreeModTime()
 	indexInfo, err := os.Stat(indexFile)
 	if err != nil {
 		http.NotFound(w, r)
@@ -219,6 +252,18 @@ func handleIndex(w http.ResponseWriter, r *http.Request) {
 	currentIndexTime := indexInfo.ModTime()
 	currentIndexSize := indexInfo.Size() // [추가] 파일 크기 정보
 
+	// [추가] 검색/필터/정렬 조건이 있으면 캐시를 거치지 않고 바로 렌더링
+	query, err := parseRomQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	if !query.isEmpty() {
+		serveFilteredIndex(w, r, indexFile, query)
+		return
+	}
+	library := getLibraryIndex()
+
 	isGzip := EnableGzip && strings.Contains(r.Header.Get("Accept-Encoding"), "gzip")
 
 	var contentToServe []byte
@@ -229,9 +274,11 @@ func handleIndex(w http.ResponseWriter, r *http.Request) {
 	isValid := !indexCache.RomsDirTime.IsZero() &&
 		indexCache.RomsDirTime.Equal(currentLatestTime) &&
 		indexCache.IndexFileTime.Equal(currentIndexTime) &&
-		indexCache.IndexFileSize == currentIndexSize // [추가] 크기 비교
+		indexCache.IndexFileSize == currentIndexSize && // [추가] 크기 비교
+		indexCache.Library == library
 
 	if isValid {
+		observeIndexCache("hit")
 		etagToServe = indexCache.ETag
 		if isGzip {
 			contentToServe = indexCache.Content
@@ -243,12 +290,15 @@ func handleIndex(w http.ResponseWriter, r *http.Request) {
 		indexCache.RUnlock()
 		indexCache.Lock()
 
-		// Double-check (Write Lock 진입 후 다시 확인)
+		// Double-check (Write Lock 진입 후 다시 확인). 다른 요청이 먼저 다시 만들었으면 hit
+		// [수정] miss 는 실제로 다시 만들 때만 기록 (동시 요청이 모두 miss 로 잡히지 않도록)
 		if !indexCache.RomsDirTime.IsZero() &&
 			indexCache.RomsDirTime.Equal(currentLatestTime) &&
 			indexCache.IndexFileTime.Equal(currentIndexTime) &&
-			indexCache.IndexFileSize == currentIndexSize {
+			indexCache.IndexFileSize == currentIndexSize &&
+			indexCache.Library == library {
 			
+			observeIndexCache("hit")
 			etagToServe = indexCache.ETag
 			if isGzip {
 				contentToServe = indexCache.Content
@@ -257,6 +307,8 @@ func handleIndex(w http.ResponseWriter, r *http.Request) {
 			}
 			indexCache.Unlock()
 		} else {
+			observeIndexCache("miss")
+			rebuildStart := time.Now()
 			rawHTML, err := os.ReadFile(indexFile)
 			if err != nil {
 				indexCache.Unlock()
@@ -264,7 +316,7 @@ func handleIndex(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			romHTML := generateRomHTML(romsDir)
+			romHTML := generatePlaySectionsHTML(library) + generateRomHTML(library.search(RomQuery{}), false)
 			finalStr := strings.Replace(string(rawHTML), "<!-- SERVER_RENDERED_CONTENT -->", romHTML, 1)
 
 			hash := md5.Sum([]byte(finalStr))
@@ -272,7 +324,12 @@ func handleIndex(w http.ResponseWriter, r *http.Request) {
 			
 			var gzipData []byte
 			if EnableGzip {
-				gzipData = compressGzip([]byte(finalStr))
+				if gzipData, err = compressGzip([]byte(finalStr)); err != nil {
+					indexCache.Unlock()
+					logger("index").Error("메인 페이지 압축 실패", "err", err)
+					http.Error(w, "Index compress failed", 500)
+					return
+				}
 			}
 
 			indexCache.RawContent = []byte(finalStr)
@@ -282,7 +339,9 @@ func handleIndex(w http.ResponseWriter, r *http.Request) {
 			indexCache.RomsDirTime = currentLatestTime
 			indexCache.IndexFileTime = currentIndexTime
 			indexCache.IndexFileSize = currentIndexSize // [추가] 크기 저장
+			indexCache.Library = library
 			indexCache.ETag = newETag
+			observeIndexRebuild(time.Since(rebuildStart))
 
 			etagToServe = newETag
 			if isGzip {
@@ -294,6 +353,9 @@ func handleIndex(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// [수정] 304 에도 ETag 를 보냄 (RFC 9110)
+	w.Header().Set("ETag", fmt.Sprintf(`"%s"`, etagToServe))
+	w.Header().Set("Cache-Control", "no-cache")
 	if match := r.Header.Get("If-None-Match"); match != "" {
 		if strings.Contains(match, etagToServe) {
 			w.WriteHeader(http.StatusNotModified)
@@ -302,31 +364,48 @@ func handleIndex(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.Header().Set("ETag", fmt.Sprintf(`"%s"`, etagToServe))
-	w.Header().Set("Cache-Control", "no-cache")
 	if isGzip {
 		w.Header().Set("Content-Encoding", "gzip")
 	}
 	w.Write(contentToServe)
 }
 
-func handleBookmark(w http.ResponseWriter, r *http.Request) {
-	filePath := "./data/bookmark.json"
-
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		os.WriteFile(filePath, []byte("[]"), 0644)
+// [추가] 검색 결과 페이지: 요청마다 달라지므로 캐시하지 않음
+func serveFilteredIndex(w http.ResponseWriter, r *http.Request, indexFile string, query RomQuery) {
+	rawHTML, err := os.ReadFile(indexFile)
+	if err != nil {
+		http.Error(w, "Index file error", 500)
+		return
+	}
+	results := getLibraryIndex().search(query)
+	romHTML := generateRomHTML(results, true)
+	// [추가] 부분 일치 결과가 없으면 유사 검색 링크를 보여줌 (자동으로 유사 검색하지 않음)
+	if len(results) == 0 && query.Text != "" && !query.Fuzzy {
+		v := r.URL.Query()
+		v.Set("fuzzy", "1")
+		romHTML = fmt.Sprintf(`<div style="text-align:center; padding:50px; color:#aaa;">검색 결과가 없습니다.<br><a href="/?%s">비슷한 제목 찾기</a></div>`, html.EscapeString(v.Encode()))
 	}
+	finalStr := strings.Replace(string(rawHTML), "<!-- SERVER_RENDERED_CONTENT -->", romHTML, 1)
 
-	bytesData, _ := os.ReadFile(filePath)
-	var bookmarks []BookmarkItem
-	json.Unmarshal(bytesData, &bookmarks)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write([]byte(finalStr))
+}
 
+func handleBookmark(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case "GET":
+		// [수정] 손상된 파일을 빈 목록으로 취급하지 않고 오류로 알림
+		bookmarks := []BookmarkItem{}
+		if _, err := bookmarkStore.Load(&bookmarks); err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+
 		if r.URL.Query().Get("format") == "html" {
 			grouped := make(map[string][]RomInfo)
 			for _, item := range bookmarks {
-				romPath := filepath.Join("./data/roms", item.System, item.Rom)
+				romPath, _ := resolveRomPath(item.System, item.Rom)
 				var size int64 = 0
 				if info, err := os.Stat(romPath); err == nil {
 					size = info.Size()
@@ -362,7 +441,7 @@ func handleBookmark(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
-		w.Write(bytesData)
+		json.NewEncoder(w).Encode(bookmarks)
 
 	case "POST":
 		var item BookmarkItem
@@ -370,14 +449,20 @@ func handleBookmark(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Invalid JSON", 400)
 			return
 		}
-		for _, b := range bookmarks {
-			if b.System == item.System && b.Rom == item.Rom {
-				w.WriteHeader(200)
-				return
+		var bookmarks []BookmarkItem
+		err := bookmarkStore.Update(&bookmarks, func() error {
+			for _, b := range bookmarks {
+				if b.System == item.System && b.Rom == item.Rom {
+					return nil
+				}
 			}
+			bookmarks = append(bookmarks, item)
+			return nil
+		})
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
 		}
-		bookmarks = append(bookmarks, item)
-		saveBookmarks(filePath, bookmarks)
 		w.WriteHeader(200)
 
 	case "DELETE":
@@ -386,22 +471,27 @@ func handleBookmark(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Invalid JSON", 400)
 			return
 		}
-		newBookmarks := []BookmarkItem{}
-		for _, b := range bookmarks {
-			if !(b.System == item.System && b.Rom == item.Rom) {
-				newBookmarks = append(newBookmarks, b)
-			}
+		if err := removeBookmarks(func(b BookmarkItem) bool { return b.System == item.System && b.Rom == item.Rom }); err != nil {
+			http.Error(w, err.Error(), 500)
+			return
 		}
-		saveBookmarks(filePath, newBookmarks)
 		w.WriteHeader(200)
 	}
 }
 
-// ... (나머지 handle functions: saveBookmarks, handleRomDelete, handleSaveUpload, handleSaveDownload, handleCoreDownload, handleInjectRom 등 유지) ...
-
-func saveBookmarks(path string, data []BookmarkItem) {
-	bytesData, _ := json.MarshalIndent(data, "", "  ")
-	os.WriteFile(path, bytesData, 0644)
+// [추가] 조건에 맞는 즐겨찾기 제거
+func removeBookmarks(match func(BookmarkItem) bool) error {
+	var bookmarks []BookmarkItem
+	return bookmarkStore.Update(&bookmarks, func() error {
+		kept := []BookmarkItem{}
+		for _, b := range bookmarks {
+			if !match(b) {
+				kept = append(kept, b)
+			}
+		}
+		bookmarks = kept
+		return nil
+	})
 }
 
 func handleRomDelete(w http.ResponseWriter, r *http.Request) {
@@ -409,6 +499,10 @@ func handleRomDelete(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", 405)
 		return
 	}
+	// [수정] 롬 삭제는 관리 API (RETRO_ADMIN_TOKEN 토큰 필요)
+	if !requireAdmin(w, r) {
+		return
+	}
 	sys := r.URL.Query().Get("sys")
 	rom := r.URL.Query().Get("rom")
 	if sys == "" || rom == "" {
@@ -417,13 +511,19 @@ func handleRomDelete(w http.ResponseWriter, r *http.Request) {
 	}
 	safeSys := filepath.Base(sys)
 	safeRom := filepath.Base(rom)
-	targetPath := filepath.Join("./data/roms", safeSys, safeRom)
-	if err := os.Remove(targetPath); err != nil {
-		log.Printf("삭제 실패: %v", err)
+	if !romExists(safeSys, safeRom) {
+		http.Error(w, "Unknown rom", 404)
+		return
+	}
+	// [수정] 바로 지우지 않고 휴지통으로 이동 (trash.go)
+	item, err := moveRomToTrash(safeSys, safeRom, requestUser(r))
+	if err != nil {
+		logger("rom").Error("롬 삭제 실패", "sys", safeSys, "rom", safeRom, "err", err)
 		http.Error(w, "Delete failed", 500)
 		return
 	}
-	w.WriteHeader(200)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
 }
 
 func handleSaveUpload(w http.ResponseWriter, r *http.Request) {
@@ -437,19 +537,75 @@ func handleSaveUpload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	safeName := filepath.Base(name)
-	saveDir := "./data/saves"
-	os.MkdirAll(saveDir, 0755)
-	targetPath := filepath.Join(saveDir, safeName)
-	file, err := os.Create(targetPath)
+	// [추가] 실제 롬에 대응하는 세이브 이름만 허용 (<sys>-<rom>.sav)
+	if _, _, ok := parseSaveName(safeName); !ok {
+		http.Error(w, "Unknown save name", 404)
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSaveBytes))
 	if err != nil {
-		http.Error(w, "Create failed", 500)
+		writeUploadError(w, err, 400)
 		return
 	}
-	defer file.Close()
-	if _, err := io.Copy(file, r.Body); err != nil {
+	if len(data) == 0 {
+		http.Error(w, "Empty save", 400)
+		return
+	}
+
+	// [수정] 덮어쓰지 않고 리비전으로 보관 (saves.go)
+	savesMu.Lock()
+	defer savesMu.Unlock()
+
+	// [추가] 초기화된(전부 0) SRAM 은 저장하지 않음 (기존 세이브를 덮어쓰거나 첫 세이브로 남지 않도록)
+	if isAllZero(data) {
+		logger("save").Warn("빈 세이브 업로드 거부", "name", safeName)
+		http.Error(w, "Refusing to store zeroed save data", http.StatusUnprocessableEntity)
+		return
+	}
+	// [수정] 현재 세이브와 내용이 같으면 If-Match 와 관계없이 아무것도 하지 않음
+	// (다시 연결된 기기가 바뀌지 않은 세이브를 올릴 때 충돌본이 쌓이지 않도록)
+	current := currentSaveETag(safeName)
+	if current != "" && md5Hex(data) == current {
+		w.Header().Set("ETag", fmt.Sprintf(`"%s"`, current))
+		w.WriteHeader(200)
+		return
+	}
+
+	user := requestUser(r)
+	if err := checkStorageQuota(user, int64(len(data))); err != nil {
+		logger("save").Warn("저장 거부", "name", safeName, "user", user, "err", err)
+		writeUploadError(w, err, 500)
+		return
+	}
+
+	// [추가] 다른 기기가 먼저 저장했으면(If-Match 불일치) 현재본을 유지하고 충돌본으로 보관
+	if !saveETagMatches(r.Header.Get("If-Match"), current) {
+		conflictID, err := storeSaveConflict(safeName, data)
+		if err != nil {
+			http.Error(w, "Write failed", 500)
+			return
+		}
+		logger("save").Warn("충돌 감지, 충돌본 보관", "name", safeName, "conflict", conflictID)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", fmt.Sprintf(`"%s"`, current))
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"current": current, "conflict": conflictID})
+		return
+	}
+
+	rev, err := storeSaveRevision(safeName, data)
+	if err != nil {
+		logger("save").Error("저장 실패", "name", safeName, "err", err)
 		http.Error(w, "Write failed", 500)
 		return
 	}
+	if err := setSaveOwner(safeName, user); err != nil {
+		logger("save").Error("소유자 기록 실패", "name", safeName, "err", err)
+	}
+	if rev != "" {
+		w.Header().Set("X-Save-Revision", rev)
+	}
+	w.Header().Set("ETag", fmt.Sprintf(`"%s"`, md5Hex(data)))
 	w.WriteHeader(200)
 }
 
@@ -471,6 +627,8 @@ func handleSaveDownload(w http.ResponseWriter, r *http.Request) {
 	}
 	w.Header().Set("Content-Type", "application/octet-stream")
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", safeName))
+	// [추가] 업로드 시 If-Match 로 돌려보낼 기준 버전
+	w.Header().Set("ETag", fmt.Sprintf(`"%s"`, currentSaveETag(safeName)))
 	http.ServeFile(w, r, targetPath)
 }
 
@@ -478,6 +636,7 @@ func handleCoreDownload(w http.ResponseWriter, r *http.Request) {
 	processingMutex.Lock()
 	if processingFiles["core_download"] {
 		processingMutex.Unlock()
+		observeJob("sync", "busy")
 		http.Error(w, "이미 다운로드가 진행 중입니다.", 429)
 		return
 	}
@@ -490,11 +649,13 @@ func handleCoreDownload(w http.ResponseWriter, r *http.Request) {
 		processingMutex.Unlock()
 	}()
 
-	syncInfoPath := "./data/core_sync.json"
 	var localSync SyncInfo
 
-	if data, err := os.ReadFile(syncInfoPath); err == nil {
-		json.Unmarshal(data, &localSync)
+	if found, err := coreSyncStore.Load(&localSync); err != nil {
+		observeJob("sync", "error")
+		http.Error(w, err.Error(), 500)
+		return
+	} else if found {
 		if localSync.LastSyncTime > 0 {
 			elapsed := time.Now().Unix() - localSync.LastSyncTime
 			cooldown := int64(24 * 60 * 60)
@@ -503,6 +664,7 @@ func handleCoreDownload(w http.ResponseWriter, r *http.Request) {
 				hours := remaining / 3600
 				minutes := (remaining % 3600) / 60
 				msg := fmt.Sprintf("코어 동기화는 24시간마다 가능합니다.\n남은 시간: %d시간 %d분", hours, minutes)
+				observeJob("sync", "cooldown")
 				http.Error(w, msg, 429)
 				return
 			}
@@ -514,7 +676,7 @@ func handleCoreDownload(w http.ResponseWriter, r *http.Request) {
 	tmpBaseDir := "/tmp/cv/cores"
 	os.RemoveAll(tmpBaseDir)
 	if err := os.MkdirAll(tmpBaseDir, 0755); err != nil {
-		log.Printf("[Sync] 임시 디렉토리 생성 실패: %v", err)
+		logger("sync").Error("임시 디렉토리 생성 실패", "dir", tmpBaseDir, "err", err)
 	}
 
 	filesToSync := []string{
@@ -541,28 +703,41 @@ func handleCoreDownload(w http.ResponseWriter, r *http.Request) {
 		"melonds-wasm.data", "melonds-thread-wasm.data", "melonds-legacy-wasm.data",
 		"mednafen_psx_hw-wasm.data", "mednafen_psx_hw-thread-wasm.data", "mednafen_psx_hw-legacy-wasm.data",
 	}
+	// [추가] 롬 폴더/coreMap 에서 쓰는 다른 코어 (cores.go)
+	coreFiles = append(coreFiles, extraCoreFiles(coreFiles)...)
 
-	log.Println("[Core] 에뮬레이터 데이터 동기화 시작...")
+	logger("sync").Info("에뮬레이터 데이터 동기화 시작")
 	client := http.Client{Timeout: 300 * time.Second}
 	successCount := 0
 	totalFiles := len(filesToSync) + len(coreFiles)
 
+	syncLog := logger("sync")
 	downloadToPath := func(url, destPath string) bool {
 		if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+			syncLog.Error("디렉토리 생성 실패", "path", destPath, "err", err)
 			return false
 		}
 		resp, err := client.Get(url)
-		if err != nil || resp.StatusCode != 200 {
+		if err != nil {
+			syncLog.Warn("다운로드 실패", "url", url, "err", err)
 			return false
 		}
 		defer resp.Body.Close()
+		if resp.StatusCode != 200 {
+			syncLog.Warn("다운로드 실패", "url", url, "status", resp.StatusCode)
+			return false
+		}
 		out, err := os.Create(destPath)
 		if err != nil {
+			syncLog.Error("파일 생성 실패", "path", destPath, "err", err)
 			return false
 		}
 		defer out.Close()
-		_, err = io.Copy(out, resp.Body)
-		return err == nil
+		if _, err = io.Copy(out, resp.Body); err != nil {
+			syncLog.Warn("다운로드 중단", "url", url, "err", err)
+			return false
+		}
+		return true
 	}
 
 	for _, file := range filesToSync {
@@ -578,18 +753,37 @@ func handleCoreDownload(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 		extractDir := tmpDownPath + "_ext"
-		os.MkdirAll(extractDir, 0755)
-		exec.Command("7z", "x", tmpDownPath, "-o"+extractDir, "-y").Run()
+		if err := os.MkdirAll(extractDir, 0755); err != nil {
+			syncLog.Error("디렉토리 생성 실패", "path", extractDir, "err", err)
+			continue
+		}
+		if out, err := exec.Command("7z", "x", tmpDownPath, "-o"+extractDir, "-y").CombinedOutput(); err != nil {
+			syncLog.Error("코어 압축 해제 실패", "core", coreFile, "err", err, "output", strings.TrimSpace(string(out)))
+			continue
+		}
 		targetPath := filepath.Join(localBaseDir, "data/cores", coreFile)
-		if err := zipDirToFile(extractDir, targetPath); err == nil {
-			successCount++
+		if err := zipDirToFile(extractDir, targetPath); err != nil {
+			syncLog.Error("코어 재압축 실패", "core", coreFile, "err", err)
+			continue
 		}
+		successCount++
 	}
 
+	result := "partial"
+	switch successCount {
+	case totalFiles:
+		result = "success"
+	case 0:
+		result = "failed"
+	}
+	observeJob("sync", result)
+
 	localSync.LastSyncTime = time.Now().Unix()
-	if bytesData, err := json.Marshal(localSync); err == nil {
-		os.MkdirAll("./data", 0755)
-		os.WriteFile(syncInfoPath, bytesData, 0644)
+	localSync.LastResult = result
+	localSync.LastTotal = totalFiles
+	localSync.LastSucceeded = successCount
+	if err := coreSyncStore.Save(localSync); err != nil {
+		logger("sync").Error("동기화 기록 저장 실패", "err", err)
 	}
 	os.RemoveAll(tmpBaseDir)
 
@@ -597,20 +791,19 @@ func handleCoreDownload(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "업데이트 완료: 총 %d개 파일 중 %d개 성공. [TIMESTAMP:%d]", totalFiles, successCount, localSync.LastSyncTime)
 }
 
-func loadInjectLog() InjectLog {
-	filePath := "./data/injected.json"
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return make(InjectLog)
-	}
-	var log InjectLog
-	json.Unmarshal(data, &log)
-	return log
+func loadInjectLog() (InjectLog, error) {
+	injectLog := make(InjectLog)
+	_, err := injectLogStore.Load(&injectLog)
+	return injectLog, err
 }
 
-func saveInjectLog(log InjectLog) {
-	data, _ := json.MarshalIndent(log, "", "  ")
-	os.WriteFile("./data/injected.json", data, 0644)
+// [수정] 다른 롬의 인젝트 기록과 동시에 써도 유실되지 않도록 해당 키만 갱신
+func setInjectLog(romKey, injectKey string) error {
+	injectLog := make(InjectLog)
+	return injectLogStore.Update(&injectLog, func() error {
+		injectLog[romKey] = injectKey
+		return nil
+	})
 }
 
 func handleInjectRom(w http.ResponseWriter, r *http.Request) {
@@ -623,10 +816,19 @@ func handleInjectRom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// [추가] 작업 결과를 메트릭과 최근 작업 목록(/api/admin/status)에 기록
+	started := time.Now()
+	finish := func(result string) {
+		observeJob("inject", result)
+		recordInjectJob(InjectJob{System: sys, Rom: rom, Inject: injectParam, Result: result,
+			Started: started.Unix(), Duration: time.Since(started).Milliseconds()})
+	}
+
 	lockKey := fmt.Sprintf("inject:%s:%s", sys, rom)
 	processingMutex.Lock()
 	if processingFiles[lockKey] {
 		processingMutex.Unlock()
+		finish("busy")
 		http.Error(w, "이미 작업 중입니다.", 429)
 		return
 	}
@@ -644,8 +846,14 @@ func handleInjectRom(w http.ResponseWriter, r *http.Request) {
 	injectKey := strings.Join(injectList, ",")
 	romKey := fmt.Sprintf("%s/%s", sys, rom)
 
-	injectLog := loadInjectLog()
+	injectLog, err := loadInjectLog()
+	if err != nil {
+		finish("error")
+		http.Error(w, err.Error(), 500)
+		return
+	}
 	if savedInject, ok := injectLog[romKey]; ok && savedInject == injectKey {
+		finish("skipped")
 		w.WriteHeader(200)
 		fmt.Fprint(w, "Already injected")
 		return
@@ -653,13 +861,20 @@ func handleInjectRom(w http.ResponseWriter, r *http.Request) {
 
 	safeSys := filepath.Base(sys)
 	safeRom := filepath.Base(rom)
-	romPath := filepath.Join("./data/roms", safeSys, safeRom)
+	romPath, ok := resolveRomPath(safeSys, safeRom)
+	if !ok {
+		finish("error")
+		http.Error(w, "Unknown rom", 404)
+		return
+	}
 	romNameNoExt := strings.TrimSuffix(safeRom, filepath.Ext(safeRom))
 	workDir := filepath.Join("/tmp", "cv", romNameNoExt)
 
 	defer os.RemoveAll(workDir)
 
 	if err := unzipToDir(romPath, workDir); err != nil {
+		finish("error")
+		logger("inject").Error("롬 압축 해제 실패", "rom", romKey, "err", err)
 		http.Error(w, "Unzip failed", 500)
 		return
 	}
@@ -674,29 +889,51 @@ func handleInjectRom(w http.ResponseWriter, r *http.Request) {
 		if _, err := os.Stat(cleanPath); os.IsNotExist(err) {
 			continue
 		}
+		var err error
 		if strings.HasSuffix(strings.ToLower(cleanPath), ".zip") {
-			unzipToDir(cleanPath, workDir)
+			err = unzipToDir(cleanPath, workDir)
 		} else {
-			copyFile(cleanPath, filepath.Join(workDir, filepath.Base(cleanPath)))
+			err = copyFile(cleanPath, filepath.Join(workDir, filepath.Base(cleanPath)))
+		}
+		if err != nil {
+			finish("error")
+			logger("inject").Error("인젝트 파일 추가 실패", "rom", romKey, "file", cleanPath, "err", err)
+			http.Error(w, "Inject failed", 500)
+			return
 		}
 	}
 
 	zipTempPath := filepath.Join("/tmp", "cv", "temp_"+safeRom)
-	os.MkdirAll(filepath.Dir(zipTempPath), 0755)
+	if err := os.MkdirAll(filepath.Dir(zipTempPath), 0755); err != nil {
+		finish("error")
+		logger("inject").Error("임시 폴더 생성 실패", "path", filepath.Dir(zipTempPath), "err", err)
+		http.Error(w, "Re-zip failed", 500)
+		return
+	}
 	if err := zipDirToFile(workDir, zipTempPath); err != nil {
+		finish("error")
+		logger("inject").Error("롬 다시 압축 실패", "rom", romKey, "err", err)
 		http.Error(w, "Re-zip failed", 500)
 		return
 	}
 	defer os.Remove(zipTempPath)
 
 	if err := moveFile(zipTempPath, romPath); err != nil {
+		finish("error")
+		logger("inject").Error("롬 덮어쓰기 실패", "rom", romKey, "err", err)
 		http.Error(w, "Overwrite failed", 500)
 		return
 	}
 
-	injectLog[romKey] = injectKey
-	saveInjectLog(injectLog)
+	// [추가] 롬을 제자리에서 덮어써 폴더 시각이 바뀌지 않으므로 검색 인덱스(크기)와 SSR 캐시를 직접 무효화
+	invalidateLibraryIndex()
+	invalidateIndexCache()
 
+	if err := setInjectLog(romKey, injectKey); err != nil {
+		logger("inject").Error("인젝트 기록 저장 실패", "rom", romKey, "err", err)
+	}
+
+	finish("success")
 	w.WriteHeader(200)
 	fmt.Fprint(w, "Injection complete")
 }
@@ -714,10 +951,14 @@ func unzipToDir(src, dest string) error {
 			continue
 		}
 		if f.FileInfo().IsDir() {
-			os.MkdirAll(fpath, os.ModePerm)
+			if err := os.MkdirAll(fpath, os.ModePerm); err != nil {
+				return err
+			}
 			continue
 		}
-		os.MkdirAll(filepath.Dir(fpath), os.ModePerm)
+		if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+			return err
+		}
 		outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
 		if err != nil {
 			return err
@@ -727,9 +968,14 @@ func unzipToDir(src, dest string) error {
 			outFile.Close()
 			return err
 		}
-		io.Copy(outFile, rc)
-		outFile.Close()
+		_, err = io.Copy(outFile, rc)
 		rc.Close()
+		if cerr := outFile.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -770,7 +1016,7 @@ func zipDirToFile(srcDir, destZip string) error {
 			return err
 		}
 		defer file.Close()
-		io.Copy(writer, file)
+		_, err = io.Copy(writer, file)
 		return err
 	})
 }
@@ -785,9 +1031,12 @@ func copyFile(src, dst string) error {
 	if err != nil {
 		return err
 	}
-	defer out.Close()
-	io.Copy(out, in)
-	return err
+	// [수정] 복사 실패를 무시하면 moveFile 이 원본을 지워버리므로 오류를 돌려줌
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
 }
 
 func moveFile(src, dst string) error {
@@ -819,26 +1068,54 @@ func addHeaders(h http.Handler) http.Handler {
 	})
 }
 
+var privateDataPaths = []string{"/data/backups/", "/data/trash/"}
+
+func isPrivateDataPath(p string) bool {
+	p = path.Clean("/" + p)
+	for _, prefix := range privateDataPaths {
+		if p+"/" == prefix || strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 func wrapWithCacheHandler(fs http.Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/" || r.URL.Path == "/index.html" {
 			handleIndex(w, r)
 			return
 		}
+		// [추가] 백업 zip(모든 세이브와 설정)과 휴지통은 정적 파일로 내보내지 않음 (관리 API 로만)
+		if isPrivateDataPath(r.URL.Path) {
+			http.NotFound(w, r)
+			return
+		}
+		// [추가] 여러 장짜리 게임의 가상 재생 목록 (롬 폴더에 파일이 없음)
+		if serveVirtualPlaylist(w, r) {
+			return
+		}
+		rewriteNestedRomPath(r) // [추가] 하위 폴더에 있는 롬
 		fs.ServeHTTP(w, r)
 	}
 }
 
 // Gzip Helper
-func compressGzip(data []byte) []byte {
+func compressGzip(data []byte) ([]byte, error) {
 	var b bytes.Buffer
 	w := gzip.NewWriter(&b)
-	w.Write(data)
-	w.Close()
-	return b.Bytes()
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
 }
 
 func main() {
+	setupLogging()
+
 	fs := http.FileServer(http.Dir("."))
 	http.Handle("/", addHeaders(wrapWithCacheHandler(fs)))
 
@@ -846,12 +1123,49 @@ func main() {
 	http.HandleFunc("/api/bookmark", handleBookmark)
 	http.HandleFunc("/api/rom", handleRomDelete)
 	http.HandleFunc("/api/save", handleSaveUpload)
+	http.HandleFunc("/api/save/", handleSaveHistory)
 	http.HandleFunc("/api/load", handleSaveDownload)
+	http.HandleFunc("/api/states/", handleStates)
+	http.HandleFunc("/api/saves/export", handleSavesExport)
+	http.HandleFunc("/api/saves/import", handleSavesImport)
+	http.HandleFunc("/api/backup", handleBackup)
+	http.HandleFunc("/api/backup/", handleBackup)
+	http.HandleFunc("/api/trash", handleTrash)
+	http.HandleFunc("/api/trash/", handleTrash)
 	http.HandleFunc("/api/download-cores", handleCoreDownload)
 	http.HandleFunc("/api/rom/inject", handleInjectRom)
-
-	fmt.Println("Server started at :8080 (SSR Enabled + Optimized)")
-	if err := http.ListenAndServe(":8080", nil); err != nil {
-		log.Fatal(err)
+	http.HandleFunc("/api/rom/rename", handleRomRelocate)
+	http.HandleFunc("/api/rom/move", handleRomRelocate)
+	http.HandleFunc("/api/rom/batch", handleRomBatch)
+	http.HandleFunc("/api/rom/files", handleRomFiles)
+	http.HandleFunc("/api/cores", handleCores)
+	http.HandleFunc("/api/settings", handleSettings)
+	http.HandleFunc("/api/controllers", handleControllers)
+	http.HandleFunc("/api/controllers/", handleControllers)
+	http.HandleFunc("/api/screenshot", handleScreenshot)
+	http.HandleFunc("/api/roms", handleRomsAPI)
+	http.HandleFunc("/api/session/", handleSession)
+	http.HandleFunc("/api/stats/", handleStats)
+	http.HandleFunc("/api/collections", handleCollections)
+	http.HandleFunc("/api/collections/", handleCollections)
+	http.HandleFunc("/metrics", handleMetrics)
+	http.HandleFunc("/healthz", handleHealthz)
+	http.HandleFunc("/readyz", handleReadyz)
+	http.HandleFunc("/api/admin/status", handleAdminStatus)
+	http.HandleFunc("/api/bios", handleBios)
+	http.HandleFunc("/api/bios/", handleBios)
+	http.HandleFunc("/socket.io/", handleSocketIO)
+	http.HandleFunc("/netplay/list", handleNetplayList)
+
+	logAdminPolicy()
+	logBackupTarget()
+	go playSessionReaper()
+	go backupScheduler()
+	go trashReaper()
+
+	slog.Info("Server started (SSR Enabled + Optimized)", "addr", ":8080")
+	if err := http.ListenAndServe(":8080", accessLog(instrument(http.DefaultServeMux))); err != nil {
+		slog.Error("서버 종료", "err", err)
+		os.Exit(1)
 	}
 }
\ No newline at end of file
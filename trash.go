@@ -0,0 +1,403 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// [휴지통] 롬 삭제는 data/trash/<id>/ 로 옮기고 30일 뒤 자동으로 비운다
+// 즐겨찾기/컬렉션/인젝트 기록에서 빠진 항목과 썸네일/스크린샷도 같이 보관해 복원 시 되돌린다
+// (세이브와 상태 저장은 사용자 데이터이므로 그대로 둔다)
+const (
+	trashDir    = "./data/trash"
+	trashExpiry = 30 * 24 * time.Hour
+)
+
+type TrashItem struct {
+	ID           string          `json:"id"`
+	System       string          `json:"system"`
+	Rom          string          `json:"rom"`
+	OriginalPath string          `json:"originalPath"`
+	Virtual      bool            `json:"virtual,omitempty"` // 가상 재생 목록 (디스크 파일만 보관)
+	Size         int64           `json:"size"`
+	User         string          `json:"user"`
+	Deleted      int64           `json:"deleted"`
+	Expires      int64           `json:"expires"`
+	Bookmarked   bool            `json:"bookmarked"`
+	Collections  []string        `json:"collections,omitempty"` // 항목이 빠진 컬렉션 ID
+	InjectKey    string          `json:"injectKey,omitempty"`
+	Artifacts    []TrashArtifact `json:"artifacts,omitempty"`
+}
+
+// 함께 옮긴 파생 파일: 원래 경로 → 휴지통 안의 파일 이름
+type TrashArtifact struct {
+	Original string `json:"original"`
+	File     string `json:"file"`
+}
+
+var reTrashID = regexp.MustCompile(`^[0-9a-f]{16}$`)
+
+// 휴지통 이동/복원/비우기 직렬화 (같은 항목을 동시에 복원하는 경우 등)
+var trashMu sync.Mutex
+
+func trashItemDir(id string) string {
+	return filepath.Join(trashDir, filepath.Base(id))
+}
+
+// 롬과 함께 옮길 파생 파일 (썸네일, 스크린샷 원본)
+func romArtifacts(sys, rom string) []string {
+	return []string{
+		thumbPath(sys, rom),
+		filepath.Join(screenshotDir, sys, rom+".png"),
+		filepath.Join(screenshotDir, sys, rom+".jpeg"),
+	}
+}
+
+func writeTrashMeta(item TrashItem) error {
+	data, _ := json.MarshalIndent(item, "", "  ")
+	return writeFileAtomic(filepath.Join(trashItemDir(item.ID), "meta.json"), data, false)
+}
+
+func readTrashMeta(id string) (TrashItem, error) {
+	var item TrashItem
+	data, err := os.ReadFile(filepath.Join(trashItemDir(id), "meta.json"))
+	if err != nil {
+		return item, err
+	}
+	err = json.Unmarshal(data, &item)
+	return item, err
+}
+
+func listTrash() []TrashItem {
+	entries, _ := os.ReadDir(trashDir)
+	items := []TrashItem{}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		item, err := readTrashMeta(e.Name())
+		if err != nil {
+			continue
+		}
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Deleted > items[j].Deleted })
+	return items
+}
+
+// 롬을 휴지통으로 옮기고 관련 기록을 정리
+func moveRomToTrash(sys, rom, user string) (TrashItem, error) {
+	trashMu.Lock()
+	defer trashMu.Unlock()
+	romPath, ok := resolveRomPath(sys, rom)
+	if !ok {
+		return TrashItem{}, fmt.Errorf("Unknown rom")
+	}
+	// 가상 재생 목록은 디스크 파일만 옮김
+	virtual := isVirtualPlaylist(romPath)
+	var size int64
+	if !virtual {
+		info, err := os.Stat(romPath)
+		if err != nil {
+			return TrashItem{}, fmt.Errorf("Unknown rom")
+		}
+		size = info.Size()
+	}
+	now := time.Now()
+	item := TrashItem{
+		ID:           newID(),
+		System:       sys,
+		Rom:          rom,
+		OriginalPath: filepath.ToSlash(romPath),
+		Virtual:      virtual,
+		Size:         size,
+		User:         user,
+		Deleted:      now.Unix(),
+		Expires:      now.Add(trashExpiry).Unix(),
+	}
+	dir := trashItemDir(item.ID)
+	if err := os.MkdirAll(filepath.Join(dir, "artifacts"), 0755); err != nil {
+		return item, err
+	}
+	// 메타를 먼저 써 두어야 이동 도중 실패해도 휴지통에서 찾을 수 있음
+	if err := writeTrashMeta(item); err != nil {
+		return item, err
+	}
+	companions := romCompanions(romPath)
+	if !virtual {
+		if err := moveFile(romPath, filepath.Join(dir, rom)); err != nil {
+			os.RemoveAll(dir)
+			return item, err
+		}
+	}
+	forgetRomLocations(sys)
+
+	match := func(b BookmarkItem) bool { return b.System == sys && b.Rom == rom }
+	if bookmarks, err := loadBookmarks(); err == nil {
+		for _, b := range bookmarks {
+			if match(b) {
+				item.Bookmarked = true
+			}
+		}
+	}
+	if item.Bookmarked {
+		if err := removeBookmarks(match); err != nil {
+			logger("trash").Error("즐겨찾기 정리 실패", "sys", sys, "rom", rom, "err", err)
+		}
+	}
+	item.Collections = removeFromCollections(sys, rom)
+
+	romKey := sys + "/" + rom
+	injectLog := make(InjectLog)
+	if err := injectLogStore.Update(&injectLog, func() error {
+		item.InjectKey = injectLog[romKey]
+		delete(injectLog, romKey)
+		return nil
+	}); err != nil {
+		logger("trash").Error("인젝트 기록 정리 실패", "rom", romKey, "err", err)
+	}
+
+	for i, src := range romArtifacts(sys, rom) {
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		file := fmt.Sprintf("%d%s", i, filepath.Ext(src))
+		if err := moveFile(src, filepath.Join(dir, "artifacts", file)); err == nil {
+			item.Artifacts = append(item.Artifacts, TrashArtifact{Original: filepath.ToSlash(src), File: file})
+		}
+	}
+	// 재생 목록(.m3u)/cue 면 디스크와 트랙도 함께 보관 (남겨 두면 다음 스캔에서 따로 보이거나 재생 목록이 다시 만들어짐)
+	for i, rel := range companions {
+		src := filepath.Join(filepath.Dir(romPath), filepath.FromSlash(rel))
+		file := fmt.Sprintf("disc%d-%s", i, filepath.Base(src))
+		if err := moveFile(src, filepath.Join(dir, "artifacts", file)); err == nil {
+			item.Artifacts = append(item.Artifacts, TrashArtifact{Original: filepath.ToSlash(src), File: file})
+			item.Size += fileSize(filepath.Join(dir, "artifacts", file))
+		}
+	}
+
+	if err := writeTrashMeta(item); err != nil {
+		logger("trash").Error("메타 저장 실패", "id", item.ID, "err", err)
+	}
+	invalidateLibraryIndex()
+	invalidateIndexCache()
+	logger("trash").Info("휴지통으로 이동", "sys", sys, "rom", rom, "id", item.ID, "user", user)
+	return item, nil
+}
+
+// 모든 컬렉션의 고정 항목에서 롬을 빼고, 빠진 컬렉션 ID 를 돌려줌
+func removeFromCollections(sys, rom string) []string {
+	collectionsMu.Lock()
+	defer collectionsMu.Unlock()
+	cols, err := loadCollections()
+	if err != nil {
+		logger("trash").Error("컬렉션 정리 실패", "sys", sys, "rom", rom, "err", err)
+		return nil
+	}
+	var removed []string
+	for i := range cols {
+		items := []BookmarkItem{}
+		for _, b := range cols[i].Items {
+			if !(b.System == sys && b.Rom == rom) {
+				items = append(items, b)
+			}
+		}
+		if len(items) != len(cols[i].Items) {
+			cols[i].Items = items
+			cols[i].Updated = time.Now().Unix()
+			removed = append(removed, cols[i].ID)
+		}
+	}
+	if len(removed) > 0 {
+		if err := saveCollections(cols); err != nil {
+			logger("trash").Error("컬렉션 저장 실패", "err", err)
+			return nil
+		}
+	}
+	return removed
+}
+
+// 원래 위치로 되돌리고 정리했던 기록을 복구
+func restoreFromTrash(id string) (TrashItem, error) {
+	trashMu.Lock()
+	defer trashMu.Unlock()
+	item, err := readTrashMeta(id)
+	if err != nil {
+		return item, err
+	}
+	dir := trashItemDir(item.ID)
+	// 하위 폴더에 있던 롬은 원래 폴더로 (롬 폴더 밖이면 시스템 폴더 바로 아래로)
+	// 같은 이름이 얕은 폴더에 있어 "<폴더> - <파일 이름>" 으로 보이던 롬은 파일 이름이 롬 이름과 다름
+	sysDir := filepath.Join(romsDir, filepath.Base(item.System))
+	romPath := filepath.Join(sysDir, filepath.Base(item.Rom))
+	if orig := filepath.Clean(filepath.FromSlash(item.OriginalPath)); strings.HasSuffix(filepath.Base(item.Rom), filepath.Base(orig)) && strings.HasPrefix(orig, filepath.Clean(sysDir)+string(os.PathSeparator)) {
+		romPath = orig
+	}
+	if _, err := os.Stat(romPath); err == nil || romExists(item.System, item.Rom) {
+		return item, os.ErrExist
+	}
+	if err := os.MkdirAll(filepath.Dir(romPath), 0755); err != nil {
+		return item, err
+	}
+	if !item.Virtual {
+		if err := moveFile(filepath.Join(dir, item.Rom), romPath); err != nil {
+			return item, err
+		}
+	}
+
+	if item.Bookmarked {
+		var bookmarks []BookmarkItem
+		if err := bookmarkStore.Update(&bookmarks, func() error {
+			for _, b := range bookmarks {
+				if b.System == item.System && b.Rom == item.Rom {
+					return nil
+				}
+			}
+			bookmarks = append(bookmarks, BookmarkItem{System: item.System, Rom: item.Rom})
+			return nil
+		}); err != nil {
+			logger("trash").Error("즐겨찾기 복원 실패", "id", item.ID, "err", err)
+		}
+	}
+	if len(item.Collections) > 0 {
+		restoreToCollections(item)
+	}
+	if item.InjectKey != "" {
+		if err := setInjectLog(item.System+"/"+item.Rom, item.InjectKey); err != nil {
+			logger("trash").Error("인젝트 기록 복원 실패", "id", item.ID, "err", err)
+		}
+	}
+	for _, a := range item.Artifacts {
+		dest := filepath.FromSlash(a.Original)
+		os.MkdirAll(filepath.Dir(dest), 0755)
+		moveFile(filepath.Join(dir, "artifacts", filepath.Base(a.File)), dest)
+	}
+
+	os.RemoveAll(dir)
+	forgetRomLocations(item.System)
+	invalidateLibraryIndex()
+	invalidateIndexCache()
+	logger("trash").Info("휴지통에서 복원", "sys", item.System, "rom", item.Rom, "id", item.ID)
+	return item, nil
+}
+
+func restoreToCollections(item TrashItem) {
+	collectionsMu.Lock()
+	defer collectionsMu.Unlock()
+	cols, err := loadCollections()
+	if err != nil {
+		logger("trash").Error("컬렉션 복원 실패", "id", item.ID, "err", err)
+		return
+	}
+	for _, id := range item.Collections {
+		if i := findCollection(cols, id); i >= 0 {
+			cols[i].Items = append(cols[i].Items, BookmarkItem{System: item.System, Rom: item.Rom})
+			cols[i].Updated = time.Now().Unix()
+		}
+	}
+	if err := saveCollections(cols); err != nil {
+		logger("trash").Error("컬렉션 저장 실패", "err", err)
+	}
+}
+
+func purgeTrashItem(id string) error {
+	trashMu.Lock()
+	defer trashMu.Unlock()
+	if _, err := readTrashMeta(id); err != nil {
+		return err
+	}
+	return os.RemoveAll(trashItemDir(id))
+}
+
+// 보관 기간이 지난 항목을 주기적으로 비움
+func trashReaper() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for ; ; <-ticker.C {
+		now := time.Now().Unix()
+		for _, item := range listTrash() {
+			if item.Expires > 0 && item.Expires < now {
+				if err := purgeTrashItem(item.ID); err == nil {
+					logger("trash").Info("보관 기간 만료로 삭제", "sys", item.System, "rom", item.Rom, "id", item.ID)
+				}
+			}
+		}
+	}
+}
+
+// GET    /api/trash                 목록
+// POST   /api/trash/<id>/restore    복원
+// DELETE /api/trash/<id>            영구 삭제
+// DELETE /api/trash                 휴지통 비우기
+func handleTrash(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/trash"), "/"), "/")
+
+	switch {
+	case parts[0] == "":
+		switch r.Method {
+		case "GET":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(listTrash())
+		case "DELETE":
+			if !requireAdmin(w, r) {
+				return
+			}
+			for _, item := range listTrash() {
+				purgeTrashItem(item.ID)
+			}
+			logger("trash").Info("휴지통 비움")
+			w.WriteHeader(200)
+		default:
+			http.Error(w, "Method not allowed", 405)
+		}
+
+	case !reTrashID.MatchString(parts[0]):
+		http.Error(w, "Invalid id", 400)
+
+	case len(parts) == 1:
+		if r.Method != "DELETE" {
+			http.Error(w, "Method not allowed", 405)
+			return
+		}
+		if !requireAdmin(w, r) {
+			return
+		}
+		if err := purgeTrashItem(parts[0]); err != nil {
+			http.Error(w, "Not found", 404)
+			return
+		}
+		w.WriteHeader(200)
+
+	case len(parts) == 2 && parts[1] == "restore":
+		if r.Method != "POST" {
+			http.Error(w, "Method not allowed", 405)
+			return
+		}
+		item, err := restoreFromTrash(parts[0])
+		if os.IsNotExist(err) {
+			http.Error(w, "Not found", 404)
+			return
+		}
+		if os.IsExist(err) {
+			http.Error(w, "같은 이름의 롬이 이미 있습니다", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Restore failed", 500)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(item)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestRom(t *testing.T, sys, rom, content string) string {
+	t.Helper()
+	p := filepath.Join(romsDir, sys, rom)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	forgetRomLocations(sys)
+	invalidateLibraryIndex()
+	return p
+}
+
+func TestTrashMoveAndRestore(t *testing.T) {
+	t.Chdir(t.TempDir())
+	romPath := writeTestRom(t, "snes", "Game.sfc", "rom data")
+	if err := bookmarkStore.Save([]BookmarkItem{{System: "snes", Rom: "Game.sfc"}, {System: "nes", Rom: "Other.nes"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	item, err := moveRomToTrash("snes", "Game.sfc", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(romPath); !os.IsNotExist(err) {
+		t.Errorf("rom still in library after trash: %v", err)
+	}
+	if !item.Bookmarked || item.User != "alice" || item.Size != int64(len("rom data")) {
+		t.Errorf("trash item = %+v", item)
+	}
+	if bookmarks, _ := loadBookmarks(); len(bookmarks) != 1 || bookmarks[0].Rom != "Other.nes" {
+		t.Errorf("bookmarks after trash = %+v", bookmarks)
+	}
+	if list := listTrash(); len(list) != 1 || list[0].ID != item.ID {
+		t.Fatalf("listTrash = %+v", list)
+	}
+
+	if _, err := restoreFromTrash(item.ID); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(romPath); err != nil || string(data) != "rom data" {
+		t.Errorf("restored rom = %q, %v", data, err)
+	}
+	if bookmarks, _ := loadBookmarks(); len(bookmarks) != 2 {
+		t.Errorf("bookmarks after restore = %+v", bookmarks)
+	}
+	if list := listTrash(); len(list) != 0 {
+		t.Errorf("trash not empty after restore: %+v", list)
+	}
+}
+
+func TestTrashRestoreConflict(t *testing.T) {
+	t.Chdir(t.TempDir())
+	romPath := writeTestRom(t, "snes", "Game.sfc", "old")
+	item, err := moveRomToTrash("snes", "Game.sfc", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestRom(t, "snes", "Game.sfc", "new")
+
+	if _, err := restoreFromTrash(item.ID); !os.IsExist(err) {
+		t.Fatalf("restore over existing rom: err = %v, want ErrExist", err)
+	}
+	if data, _ := os.ReadFile(romPath); string(data) != "new" {
+		t.Errorf("existing rom overwritten: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(trashItemDir(item.ID), "Game.sfc")); err != nil {
+		t.Errorf("trashed rom lost after failed restore: %v", err)
+	}
+}
+
+func TestTrashPurgeRequiresAdmin(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeTestRom(t, "snes", "Game.sfc", "rom data")
+	item, err := moveRomToTrash("snes", "Game.sfc", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(adminTokenEnv, "secret")
+
+	tests := []struct {
+		name   string
+		target string
+		token  string
+		status int
+	}{
+		{"purge without token", "/api/trash/" + item.ID, "", 401},
+		{"empty without token", "/api/trash", "wrong", 401},
+		{"purge with token", "/api/trash/" + item.ID, "secret", 200},
+		{"purge again", "/api/trash/" + item.ID, "secret", 404},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("DELETE", tt.target, nil)
+		r.Header.Set("X-Admin-Token", tt.token)
+		w := httptest.NewRecorder()
+		handleTrash(w, r)
+		if w.Code != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.name, w.Code, tt.status)
+		}
+	}
+	if _, err := os.Stat(trashItemDir(item.ID)); !os.IsNotExist(err) {
+		t.Errorf("trash item still on disk after purge: %v", err)
+	}
+}